@@ -0,0 +1,169 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/simulation"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type (
+	// MapFactory returns a new, independent world map that draws all of its
+	// randomness from the given random number generator, e.g. a clone of a
+	// template map. It may be called concurrently.
+	MapFactory func(r *rng.Rand) (*world.Map, error)
+
+	// Config reflects the configuration of a batch of simulations.
+	Config struct {
+		// Runs is the number of simulations to run.
+		Runs uint
+		// Aliens is the number of aliens to seed in every simulation. It must
+		// be zero if the map already places its aliens.
+		Aliens uint
+		// Seed is the seed of the first run. Run i is seeded with Seed+i.
+		Seed int64
+		// Workers is the number of simulations run in parallel. If zero, one
+		// worker per CPU is used.
+		Workers int
+		// Simulation is the configuration every simulation is bound by.
+		Simulation simulation.Config
+		// Budget is the budget every simulation is bound by. A simulation that
+		// exceeds its budget is stopped and its outcome is still accounted
+		// for.
+		Budget simulation.Budget
+	}
+
+	// Result reflects the outcome of a single simulation of a batch.
+	Result struct {
+		Run             uint              `json:"run"`
+		Seed            int64             `json:"seed"`
+		Reason          simulation.Reason `json:"reason"`
+		Ticks           uint64            `json:"ticks"`
+		CitiesDestroyed []string          `json:"cities_destroyed"`
+	}
+)
+
+// Run runs a batch of independent simulations across a pool of workers. Every
+// simulation runs on its own map returned by newMap using its own seed. The
+// results are returned in the order of the runs regardless of the number of
+// workers, so a batch is reproducible given the same configuration. An error
+// is returned if the configuration is invalid, any map cannot be created or
+// any simulation fails. If the given context is done, the batch is stopped and
+// the context's error is returned.
+func Run(ctx context.Context, newMap MapFactory, cfg Config) ([]Result, error) {
+	if cfg.Runs == 0 {
+		return nil, errors.New("invalid number of runs: must be greater than zero")
+	}
+
+	if err := cfg.Simulation.Validate(); err != nil {
+		return nil, err
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results = make([]Result, cfg.Runs)
+		runs    = make(chan uint)
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		runErr  error
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for run := range runs {
+				result, err := runOne(ctx, newMap, cfg, run)
+				if err != nil {
+					errOnce.Do(func() {
+						runErr = err
+						cancel()
+					})
+
+					continue
+				}
+
+				results[run] = result
+			}
+		}()
+	}
+
+	for run := uint(0); run < cfg.Runs && ctx.Err() == nil; run++ {
+		select {
+		case runs <- run:
+		case <-ctx.Done():
+		}
+	}
+
+	close(runs)
+	wg.Wait()
+
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// runOne runs a single simulation of a batch.
+func runOne(ctx context.Context, newMap MapFactory, cfg Config, run uint) (Result, error) {
+	seed := cfg.Seed + int64(run)
+
+	alienMap, err := newMap(rng.NewRand(seed))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if alienMap.NumAliens() != 0 && cfg.Aliens != 0 {
+		return Result{}, errors.New("invalid number of aliens: map already places aliens")
+	} else if alienMap.NumAliens() == 0 && cfg.Aliens == 0 {
+		return Result{}, errors.New("invalid number of aliens: must be greater than zero")
+	} else if cfg.Aliens > alienMap.NumCities()*alienMap.Config().Capacity {
+		return Result{}, errors.New("invalid number of aliens: cities cannot hold that many aliens")
+	}
+
+	sim, err := simulation.NewSimulationWithConfig(alienMap, cfg.Simulation)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := sim.Seed(cfg.Aliens); err != nil {
+		return Result{}, err
+	}
+
+	err = sim.RunContext(ctx, cfg.Budget)
+	if _, ok := err.(*simulation.BudgetExceededError); !ok && err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Run:             run,
+		Seed:            seed,
+		Reason:          sim.Reason(),
+		Ticks:           sim.Tick(),
+		CitiesDestroyed: make([]string, 0),
+	}
+
+	for _, d := range alienMap.DestroyedCities() {
+		result.CitiesDestroyed = append(result.CitiesDestroyed, d.City)
+	}
+
+	return result, nil
+}
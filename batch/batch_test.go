@@ -0,0 +1,86 @@
+package batch
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/simulation"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func newMapFixture(r *rng.Rand) (*world.Map, error) {
+	return buildMapTemplate().CloneWithRand(r), nil
+}
+
+func buildMapTemplate() *world.Map {
+	m := world.NewMap(rng.NewRand(0))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "east", "baz")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("bar", "east", "qux")
+	m.AddLink("baz", "west", "foo")
+	m.AddLink("baz", "north", "qux")
+	m.AddLink("qux", "west", "bar")
+	m.AddLink("qux", "south", "baz")
+
+	return m
+}
+
+func TestRun(t *testing.T) {
+	cfg := Config{
+		Runs:       20,
+		Aliens:     3,
+		Seed:       1,
+		Workers:    1,
+		Simulation: simulation.Config{MinAlienMoves: 100},
+	}
+
+	sequential, err := Run(context.Background(), newMapFixture, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sequential) != 20 {
+		t.Fatalf("incorrect result: expected: %v, got: %v", 20, len(sequential))
+	}
+
+	for i, result := range sequential {
+		if result.Run != uint(i) || result.Seed != int64(i+1) {
+			t.Errorf("incorrect result: expected: run %d with seed %d, got: %v", i, i+1, result)
+		}
+	}
+
+	cfg.Workers = 4
+
+	parallel, err := Run(context.Background(), newMapFixture, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(parallel, sequential) {
+		t.Errorf("incorrect result: expected: %v, got: %v", sequential, parallel)
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	if _, err := Run(context.Background(), newMapFixture, Config{Aliens: 2, Simulation: simulation.DefaultConfig()}); err == nil {
+		t.Error("expected error for zero runs")
+	}
+
+	cfg := Config{Runs: 5, Aliens: 9, Simulation: simulation.DefaultConfig()}
+
+	if _, err := Run(context.Background(), newMapFixture, cfg); err == nil {
+		t.Error("expected error for too many aliens")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg.Aliens = 2
+	if _, err := Run(ctx, newMapFixture, cfg); err != context.Canceled {
+		t.Errorf("incorrect result: expected: %v, got: %v", context.Canceled, err)
+	}
+}
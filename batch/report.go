@@ -0,0 +1,205 @@
+package batch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/alexanderbez/alien-invasion/simulation"
+)
+
+type (
+	// Report reflects the aggregated outcomes of a batch of simulations.
+	Report struct {
+		Runs uint `json:"runs"`
+		// CitiesDestroyed reflects the distribution of the number of cities
+		// destroyed per run.
+		CitiesDestroyed Distribution `json:"cities_destroyed"`
+		// SurvivalTicks reflects the distribution of the number of ticks a
+		// run lasted until the simulation terminated.
+		SurvivalTicks Distribution `json:"survival_ticks"`
+		// CityDestruction reflects the probability of every city of the map
+		// being destroyed in a run, in the order the cities are defined.
+		CityDestruction []CityProbability `json:"city_destruction"`
+		// Reasons reflects the number of runs that terminated for every
+		// termination reason.
+		Reasons map[simulation.Reason]uint `json:"reasons"`
+	}
+
+	// Distribution reflects summary statistics of a sample of values.
+	Distribution struct {
+		Min    float64 `json:"min"`
+		Mean   float64 `json:"mean"`
+		StdDev float64 `json:"stddev"`
+		P50    float64 `json:"p50"`
+		P90    float64 `json:"p90"`
+		Max    float64 `json:"max"`
+	}
+
+	// CityProbability reflects the probability of a city being destroyed.
+	CityProbability struct {
+		City        string  `json:"city"`
+		Probability float64 `json:"probability"`
+	}
+)
+
+// NewReport aggregates the results of a batch of simulations of a map with
+// the given cities into a report.
+func NewReport(cities []string, results []Result) Report {
+	report := Report{
+		Runs:            uint(len(results)),
+		CityDestruction: make([]CityProbability, 0, len(cities)),
+		Reasons:         make(map[simulation.Reason]uint),
+	}
+
+	var (
+		destroyed = make([]float64, len(results))
+		ticks     = make([]float64, len(results))
+		counts    = make(map[string]uint, len(cities))
+	)
+
+	for i, result := range results {
+		destroyed[i] = float64(len(result.CitiesDestroyed))
+		ticks[i] = float64(result.Ticks)
+		report.Reasons[result.Reason]++
+
+		for _, city := range result.CitiesDestroyed {
+			counts[city]++
+		}
+	}
+
+	report.CitiesDestroyed = newDistribution(destroyed)
+	report.SurvivalTicks = newDistribution(ticks)
+
+	for _, city := range cities {
+		cp := CityProbability{City: city}
+		if len(results) != 0 {
+			cp.Probability = float64(counts[city]) / float64(len(results))
+		}
+
+		report.CityDestruction = append(report.CityDestruction, cp)
+	}
+
+	return report
+}
+
+// newDistribution returns the distribution of the given values. Percentiles
+// are computed using the nearest-rank method.
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	mean := sum / float64(len(sorted))
+
+	var variance float64
+	for _, v := range sorted {
+		variance += (v - mean) * (v - mean)
+	}
+
+	variance /= float64(len(sorted))
+
+	return Distribution{
+		Min:    sorted[0],
+		Mean:   mean,
+		StdDev: math.Sqrt(variance),
+		P50:    percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the p-th percentile of the given sorted values using the
+// nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// WriteJSON writes the report to the given writer as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the report to the given writer as CSV in long format, i.e.
+// every row consists of a metric, a key and a value:
+//
+//	metric,key,value
+//	runs,,1000
+//	cities_destroyed,mean,2.5
+//	city_destruction,Foo,0.42
+//	reason,all aliens destroyed,730
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"metric", "key", "value"},
+		{"runs", "", formatUint(r.Runs)},
+	}
+
+	rows = append(rows, distributionRows("cities_destroyed", r.CitiesDestroyed)...)
+	rows = append(rows, distributionRows("survival_ticks", r.SurvivalTicks)...)
+
+	for _, cp := range r.CityDestruction {
+		rows = append(rows, []string{"city_destruction", cp.City, formatFloat(cp.Probability)})
+	}
+
+	reasons := make([]string, 0, len(r.Reasons))
+	for reason := range r.Reasons {
+		reasons = append(reasons, string(reason))
+	}
+
+	sort.Strings(reasons)
+
+	for _, reason := range reasons {
+		rows = append(rows, []string{"reason", reason, formatUint(r.Reasons[simulation.Reason(reason)])})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// distributionRows returns the CSV rows of a distribution of a given metric.
+func distributionRows(metric string, d Distribution) [][]string {
+	return [][]string{
+		{metric, "min", formatFloat(d.Min)},
+		{metric, "mean", formatFloat(d.Mean)},
+		{metric, "stddev", formatFloat(d.StdDev)},
+		{metric, "p50", formatFloat(d.P50)},
+		{metric, "p90", formatFloat(d.P90)},
+		{metric, "max", formatFloat(d.Max)},
+	}
+}
+
+// formatUint formats an unsigned integer for CSV output.
+func formatUint(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// formatFloat formats a float for CSV output using the fewest digits
+// necessary.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
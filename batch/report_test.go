@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/simulation"
+)
+
+func TestNewReport(t *testing.T) {
+	results := []Result{
+		{Run: 0, Reason: simulation.ReasonAllDestroyed, Ticks: 10, CitiesDestroyed: []string{"foo", "bar"}},
+		{Run: 1, Reason: simulation.ReasonAllTrapped, Ticks: 20, CitiesDestroyed: []string{"foo"}},
+		{Run: 2, Reason: simulation.ReasonAllDestroyed, Ticks: 30, CitiesDestroyed: []string{}},
+		{Run: 3, Reason: simulation.ReasonAllDestroyed, Ticks: 40, CitiesDestroyed: []string{"foo"}},
+	}
+
+	report := NewReport([]string{"foo", "bar", "baz"}, results)
+
+	e := Report{
+		Runs:            4,
+		CitiesDestroyed: Distribution{Min: 0, Mean: 1, StdDev: 0.7071067811865476, P50: 1, P90: 2, Max: 2},
+		SurvivalTicks:   Distribution{Min: 10, Mean: 25, StdDev: 11.180339887498949, P50: 20, P90: 40, Max: 40},
+		CityDestruction: []CityProbability{{"foo", 0.75}, {"bar", 0.25}, {"baz", 0}},
+		Reasons: map[simulation.Reason]uint{
+			simulation.ReasonAllDestroyed: 3,
+			simulation.ReasonAllTrapped:   1,
+		},
+	}
+
+	if !reflect.DeepEqual(report, e) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", e, report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	csv := "metric,key,value\n" +
+		"runs,,4\n" +
+		"cities_destroyed,min,0\n" +
+		"cities_destroyed,mean,1\n" +
+		"cities_destroyed,stddev,0.7071067811865476\n" +
+		"cities_destroyed,p50,1\n" +
+		"cities_destroyed,p90,2\n" +
+		"cities_destroyed,max,2\n" +
+		"survival_ticks,min,10\n" +
+		"survival_ticks,mean,25\n" +
+		"survival_ticks,stddev,11.180339887498949\n" +
+		"survival_ticks,p50,20\n" +
+		"survival_ticks,p90,40\n" +
+		"survival_ticks,max,40\n" +
+		"city_destruction,foo,0.75\n" +
+		"city_destruction,bar,0.25\n" +
+		"city_destruction,baz,0\n" +
+		"reason,all aliens destroyed,3\n" +
+		"reason,all remaining aliens trapped,1\n"
+
+	if buf.String() != csv {
+		t.Errorf("incorrect result: expected: %q, got: %q", csv, buf.String())
+	}
+}
@@ -1,148 +1,740 @@
 package main
 
 import (
-	"bufio"
-	"errors"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/alexanderbez/alien-invasion/batch"
+	"github.com/alexanderbez/alien-invasion/mapfile"
+	"github.com/alexanderbez/alien-invasion/rng"
 	"github.com/alexanderbez/alien-invasion/simulation"
 	"github.com/alexanderbez/alien-invasion/world"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "replay":
+			os.Exit(runReplay(os.Args[2:]))
+		case "batch":
+			os.Exit(runBatch(os.Args[2:]))
+		}
+	}
+
 	var (
-		mapFile   string
-		outFile   string
-		numAliens uint
+		mapFile       string
+		outFile       string
+		destroyedFile string
+		dotFile       string
+		dotBeforeFile string
+		eventsFile    string
+		recordFile    string
+		summaryFile   string
+		checkpoint    string
+		resumeFile    string
+		numAliens     uint
+		every         uint64
+		maxTicks      uint64
+		timeout       time.Duration
+		seed          int64
+		strictness    string
+		inFormat      string
+		outFormat     string
+		strategySpec  string
+		alienSpecs    string
+		resolverSpec  string
+		rosterFile    string
+		worldCfg      = world.DefaultConfig()
+		simCfg        = simulation.DefaultConfig()
 	)
 
 	flag.StringVar(&mapFile, "map", "", "file containing the map definition")
 	flag.StringVar(&outFile, "out", "", "output file to write resulting map to")
-	flag.UintVar(&numAliens, "n", 0, "number of aliens to use in the simulation")
+	flag.StringVar(&destroyedFile, "destroyed", "", "optional output file to write destroyed cities to")
+	flag.StringVar(&dotFile, "dot", "", "optional output file to write the resulting map to as a Graphviz DOT graph")
+	flag.StringVar(&eventsFile, "events", "", "optional output file to write simulation events to as JSON Lines")
+	flag.StringVar(&summaryFile, "summary", "", "optional output file to write the summary of the simulation to as JSON")
+	flag.StringVar(&recordFile, "record", "", "optional output file to write a replayable recording of the simulation to")
+	flag.StringVar(&checkpoint, "checkpoint", "", "optional file to write checkpoints of the simulation to, including when it is stopped")
+	flag.Uint64Var(&every, "checkpoint-every", 0, "optional number of ticks between periodic checkpoints (requires --checkpoint)")
+	flag.StringVar(&resumeFile, "resume", "", "optional checkpoint file to resume a simulation from instead of starting a new one")
+	flag.StringVar(&dotBeforeFile, "dot-before", "", "optional output file to write the seeded map to as a Graphviz DOT graph")
+	flag.UintVar(&numAliens, "n", 0, "number of aliens to use in the simulation (optional if the map places aliens)")
+	flag.StringVar(&rosterFile, "roster", "", "optional file containing the attributes of aliens and the species to seed them from")
+	flag.Uint64Var(&maxTicks, "max-ticks", 0, "optional maximum number of ticks before the simulation is stopped")
+	flag.DurationVar(&timeout, "timeout", 0, "optional maximum wall time before the simulation is stopped (e.g. 30s)")
+	flag.Int64Var(&seed, "seed", 0, "seed for the random number generator (default derived from the current time)")
+	flag.StringVar(&strictness, "strictness", "lenient", "road consistency enforcement: lenient, strict or repair")
+	flag.StringVar(&inFormat, "in-format", "auto", "format of the map definition: auto, text or json")
+	flag.StringVar(&outFormat, "out-format", "auto", "format of the resulting map: auto, text or json")
+	registerRuleFlags(flag.CommandLine, &worldCfg, &simCfg, &resolverSpec)
+	registerStrategyFlags(flag.CommandLine, &strategySpec, &alienSpecs)
 
 	flag.Parse()
 
-	if len(mapFile) == 0 {
+	if !isFlagSet("seed") {
+		seed = time.Now().UnixNano()
+	}
+
+	if len(mapFile) == 0 && len(resumeFile) == 0 {
 		cmdErrorMsg("invalid map definition: no file specified")
+	} else if len(mapFile) != 0 && len(resumeFile) != 0 {
+		cmdErrorMsg("invalid map definition: cannot both resume and load a map")
 	} else if len(outFile) == 0 {
 		cmdErrorMsg("invalid output definition: no file specified")
-	} else if numAliens == 0 {
-		cmdErrorMsg("invalid number of aliens: must be greater than zero")
+	} else if every != 0 && len(checkpoint) == 0 {
+		cmdErrorMsg("invalid checkpoint definition: no file specified")
+	}
+
+	mode, err := mapfile.ParseStrictness(strictness)
+	if err != nil {
+		cmdErrorMsg(err.Error())
+	}
+
+	inFmt, err := mapfile.ParseFormat(inFormat)
+	if err != nil {
+		cmdErrorMsg(err.Error())
+	}
+
+	outFmt, err := mapfile.ParseFormat(outFormat)
+	if err != nil {
+		cmdErrorMsg(err.Error())
+	}
+
+	if err := worldCfg.Validate(); err != nil {
+		cmdErrorMsg(err.Error())
 	}
 
-	worldMap, err := buildWorldMap(mapFile)
+	if err := simCfg.Validate(); err != nil {
+		cmdErrorMsg(err.Error())
+	}
+
+	strategy, alienStrategies, err := parseStrategies(strategySpec, alienSpecs)
 	if err != nil {
-		log.Fatalf("failed to build map from file: %v", err)
+		cmdErrorMsg(err.Error())
 	}
 
-	// We assume there can be no more than twice the number of aliens as there
-	// are cities. In otherwords, upon seeding the map with aliens, at most each
-	// can be occupied by two aliens.
-	if numAliens > worldMap.NumCities()*2 {
-		log.Fatalf("invalid number of aliens: cannot have more than 2x of unique cities")
+	resolver, err := world.ParseFightResolver(resolverSpec)
+	if err != nil {
+		cmdErrorMsg(err.Error())
 	}
 
-	// Seed the map with 'n' aliens scattered randomly throughout the city and
-	// invoke an initial series of alien fights where a search of the map
-	// (graph) is done looking for city alien occupation equal to MaxOccupancy.
-	worldMap.SeedAliens(numAliens)
-	worldMap.ExecuteFights()
+	var sim *simulation.Simulation
+
+	if len(resumeFile) != 0 {
+		if numAliens != 0 {
+			cmdErrorMsg("invalid number of aliens: a resumed simulation already places aliens")
+		}
+
+		// The rules, movement strategies, fight resolver, alien attributes
+		// and random number generator state are restored from the
+		// checkpoint, so none of them may be set.
+		for _, name := range []string{
+			"seed", "strictness", "in-format", "min-moves", "mode", "capacity", "fight-threshold",
+			"max-edges", "fight-resolver", "strategy", "alien-strategy", "roster",
+		} {
+			if isFlagSet(name) {
+				cmdErrorMsg(fmt.Sprintf("invalid flag --%s: a resumed simulation restores it from the checkpoint", name))
+			}
+		}
+
+		sim, err = resumeSimulation(resumeFile)
+		if err != nil {
+			log.Fatalf("failed to resume simulation: %v", err)
+		}
+
+		log.Printf("resuming simulation at tick %d", sim.Tick())
+	} else {
+		// Log the seed in use so that any run can be reproduced exactly.
+		log.Printf("using seed: %d", seed)
+
+		worldMap, diags, err := mapfile.LoadFile(mapFile, inFmt, rng.NewRand(seed), worldCfg, mode)
+		for _, d := range diags {
+			// Errors are reported as part of the returned error.
+			if d.Severity != mapfile.SeverityError {
+				log.Println(d)
+			}
+		}
+
+		if err != nil {
+			log.Fatalf("failed to build map from file: %v", err)
+		}
+
+		setStrategies(worldMap, strategy, alienStrategies)
+		worldMap.SetFightResolver(resolver)
+
+		if len(rosterFile) != 0 {
+			if err := setRoster(worldMap, rosterFile); err != nil {
+				log.Fatalf("failed to load roster: %v", err)
+			}
+		}
+
+		// A map may already place its aliens, in which case no additional
+		// aliens are seeded.
+		if worldMap.NumAliens() != 0 && numAliens != 0 {
+			cmdErrorMsg("invalid number of aliens: map definition already places aliens")
+		} else if worldMap.NumAliens() == 0 && numAliens == 0 {
+			cmdErrorMsg("invalid number of aliens: must be greater than zero")
+		}
+
+		// We assume there can be no more aliens than the cities can hold. In
+		// otherwords, upon seeding the map with aliens, at most each city can
+		// be occupied by its capacity of aliens.
+		if numAliens > worldMap.NumCities()*worldCfg.Capacity {
+			log.Fatalf("invalid number of aliens: cannot have more than %dx of unique cities", worldCfg.Capacity)
+		}
+
+		sim, err = simulation.NewSimulationWithConfig(worldMap, simCfg)
+		if err != nil {
+			log.Fatalf("failed to create simulation: %v", err)
+		}
+	}
+
+	worldMap := sim.Map()
+
+	sim.Subscribe(simulation.NewLogSubscriber(log.New(os.Stderr, "", log.LstdFlags)))
+
+	// The recorder must capture the map before any aliens are seeded.
+	var recorder *simulation.Recorder
+
+	if len(recordFile) != 0 {
+		recorder, err = simulation.NewRecorder(worldMap)
+		if err != nil {
+			log.Fatalf("failed to record simulation: %v", err)
+		}
+
+		sim.Subscribe(recorder)
+	}
+
+	var events *simulation.JSONLinesSubscriber
+
+	if len(eventsFile) != 0 {
+		file, err := os.Create(eventsFile)
+		if err != nil {
+			log.Fatalf("failed to create events file: %v", err)
+		}
+		defer file.Close()
+
+		events = simulation.NewJSONLinesSubscriber(file)
+		sim.Subscribe(events)
+	}
+
+	// Seed the map with 'n' aliens scattered randomly throughout the map. Any
+	// resulting fights are executed once the simulation runs. A resumed
+	// simulation is never seeded, as seeding draws from the restored random
+	// number generator even if no aliens are added.
+	if len(resumeFile) == 0 {
+		if err := sim.Seed(numAliens); err != nil {
+			log.Fatalf("failed to seed aliens: %v", err)
+		}
+	}
+
+	if len(dotBeforeFile) != 0 {
+		if err := mapfile.WriteDOTFile(dotBeforeFile, worldMap); err != nil {
+			log.Fatalf("failed to write DOT graph to file: %v", err)
+		}
+	}
+
+	// Interrupting a run stops it gracefully so the resulting map is still
+	// written.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
 
-	sim := simulation.NewSimulation(worldMap)
+	go func() {
+		<-interrupts
+		cancel()
+	}()
 
-	if err := sim.Run(); err != nil {
-		log.Fatalf("failed to execute alien invasion simulation: %v", err)
+	if every != 0 {
+		sim.CheckpointEvery(every, func(cp *simulation.Checkpoint) error {
+			return writeCheckpoint(checkpoint, cp)
+		})
 	}
 
-	log.Println("simulation complete")
+	runErr := sim.RunContext(ctx, simulation.Budget{MaxTicks: maxTicks, MaxDuration: timeout})
+	signal.Stop(interrupts)
 
-	if err := writeMapToFile(worldMap, outFile); err != nil {
+	// Flush the events first so that the trace of a failed run is kept.
+	if events != nil {
+		if err := events.Flush(); err != nil {
+			log.Fatalf("failed to write events to file: %v", err)
+		}
+	}
+
+	if recorder != nil {
+		if err := writeRecording(recordFile, recorder); err != nil {
+			log.Fatalf("failed to write recording to file: %v", err)
+		}
+	}
+
+	switch runErr.(type) {
+	case nil:
+		log.Printf("simulation complete: %s", sim.Reason())
+
+	case *simulation.BudgetExceededError:
+		log.Printf("simulation stopped: %v", runErr)
+		saveCheckpoint(checkpoint, sim)
+
+	default:
+		if runErr != context.Canceled {
+			log.Fatalf("failed to execute alien invasion simulation: %v", runErr)
+		}
+
+		log.Printf("simulation stopped: interrupted at tick %d", sim.Tick())
+		saveCheckpoint(checkpoint, sim)
+	}
+
+	summary := sim.Summary()
+	fmt.Print(summary)
+
+	if len(summaryFile) != 0 {
+		if err := writeSummary(summaryFile, summary); err != nil {
+			log.Fatalf("failed to write summary to file: %v", err)
+		}
+	}
+
+	if err := mapfile.WriteFile(outFile, outFmt, worldMap); err != nil {
 		log.Fatalf("failed to write map to file: %v", err)
 	}
+
+	if len(destroyedFile) != 0 {
+		if err := mapfile.WriteDestroyedFile(destroyedFile, worldMap); err != nil {
+			log.Fatalf("failed to write destroyed cities to file: %v", err)
+		}
+	}
+
+	if len(dotFile) != 0 {
+		if err := mapfile.WriteDOTFile(dotFile, worldMap); err != nil {
+			log.Fatalf("failed to write DOT graph to file: %v", err)
+		}
+	}
 }
 
-func cmdErrorMsg(errMsg string) {
-	fmt.Printf("%s\n\n", errMsg)
-	fmt.Println("usage:")
-	flag.PrintDefaults()
-	os.Exit(1)
+// registerRuleFlags registers the flags that configure the rules of a
+// simulation with the given flag set.
+func registerRuleFlags(flags *flag.FlagSet, worldCfg *world.Config, simCfg *simulation.Config, resolver *string) {
+	flags.UintVar(&simCfg.MinAlienMoves, "min-moves", simCfg.MinAlienMoves, "minimum number of moves every alien must make before the simulation terminates")
+	flags.StringVar((*string)(&simCfg.Mode), "mode", string(simCfg.Mode), "how aliens move: sequential (one alien per tick) or round (all aliens per tick)")
+	flags.UintVar(&worldCfg.Capacity, "capacity", worldCfg.Capacity, "maximum number of aliens that may occupy a city")
+	flags.UintVar(&worldCfg.FightThreshold, "fight-threshold", worldCfg.FightThreshold, "number of aliens occupying a city at which they fight and destroy it")
+	flags.UintVar(&worldCfg.MaxEdges, "max-edges", worldCfg.MaxEdges, "maximum number of roads leading out of a city")
+	flags.StringVar(resolver, "fight-resolver", "destroy", "how fights are resolved: destroy, winner:<p>, damage:<n>, roads or strength")
 }
 
-// buildWorldMap builds a map from a give file. The map definition file has one
-// city per line. The city name is first, followed by 1-4 directions
-// (north, south, east, or west). Each one represents a road to another city
-// that lies in that direction. The city and each of the pairs are separated by
-// a single space, and the directions are separated from their respective
-// cities with an equals (=) sign. An error is returned if reading the file
-// fails at any point or if the map definition does not adhere to the given
-// schema.
-func buildWorldMap(mapFile string) (*world.Map, error) {
-	file, err := os.Open(mapFile)
+// registerStrategyFlags registers the flags that select the movement
+// strategies of aliens with the given flag set.
+func registerStrategyFlags(flags *flag.FlagSet, strategy, alienStrategies *string) {
+	flags.StringVar(strategy, "strategy", "random", "movement strategy of all aliens: random, unvisited, seek, avoid or lazy:<p>")
+	flags.StringVar(alienStrategies, "alien-strategy", "", "optional comma separated movement strategies of individual aliens (e.g. alien1=seek,alien2=lazy:0.3)")
+}
+
+// parseStrategies parses the default movement strategy and the comma separated
+// list of movement strategies of individual aliens, each of the form
+// <alien>=<strategy>. An error is returned if any strategy is invalid.
+func parseStrategies(spec, alienSpecs string) (world.MovementStrategy, map[string]world.MovementStrategy, error) {
+	strategy, err := world.ParseStrategy(spec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	alienStrategies := make(map[string]world.MovementStrategy)
+	if len(alienSpecs) == 0 {
+		return strategy, alienStrategies, nil
+	}
+
+	for _, alienSpec := range strings.Split(alienSpecs, ",") {
+		i := strings.IndexByte(alienSpec, '=')
+		if i <= 0 {
+			return nil, nil, fmt.Errorf("invalid alien strategy %q: must be of the form <alien>=<strategy>", alienSpec)
+		}
+
+		alienStrategy, err := world.ParseStrategy(alienSpec[i+1:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid strategy of alien %s: %v", alienSpec[:i], err)
+		}
+
+		alienStrategies[alienSpec[:i]] = alienStrategy
+	}
+
+	return strategy, alienStrategies, nil
+}
+
+// setStrategies sets the given default movement strategy and movement
+// strategies of individual aliens on the given map.
+func setStrategies(worldMap *world.Map, strategy world.MovementStrategy, alienStrategies map[string]world.MovementStrategy) {
+	worldMap.SetStrategy(strategy)
+
+	for alienName, alienStrategy := range alienStrategies {
+		worldMap.SetAlienStrategy(alienName, alienStrategy)
+	}
+}
+
+// setRoster reads the roster from the file at the given path and sets it on
+// the given map.
+func setRoster(worldMap *world.Map, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
 	}
 	defer file.Close()
 
-	worldMap := world.NewMap()
+	roster, err := world.ReadRoster(file)
+	if err != nil {
+		return err
+	}
 
-	// Create a scanner to read each map entry line by line
-	//
-	// Note: We assume the line entry can fit into the scanner's buffer
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		tokens := strings.Split(line, " ")
+	return worldMap.SetRoster(roster)
+}
 
-		if len(tokens) == 0 {
-			return nil, errors.New("invalid line in map definition")
+// isFlagSet returns a boolean on whether or not a flag with the given name was
+// explicitly set on the command line.
+func isFlagSet(name string) (set bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
+	})
 
-		cityName := tokens[0]
+	return
+}
 
-		if len(tokens) > 1 {
-			for _, link := range tokens[1:] {
-				linkTokens := strings.Split(link, "=")
+// runValidate validates each of the given map definition files and prints
+// every problem found. It returns the process exit code, which is non-zero if
+// any file could not be read or contains errors.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	strictness := flags.String("strictness", "lenient", "road consistency enforcement: lenient, strict or repair")
 
-				if len(linkTokens) != 2 {
-					return nil, errors.New("invalid line in map definition")
-				}
+	flags.Usage = func() {
+		fmt.Println("usage: alien-invasion-sim validate [--strictness=<MODE>] <MAP_FILE>...")
+		flags.PrintDefaults()
+	}
 
-				worldMap.AddLink(cityName, linkTokens[0], linkTokens[1])
-			}
+	flags.Parse(args)
+
+	mode, err := mapfile.ParseStrictness(*strictness)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	exitCode := 0
+
+	for _, file := range flags.Args() {
+		diags, err := mapfile.ValidateFile(file, mode)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			exitCode = 1
+			continue
 		}
+
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+
+		if mapfile.HasErrors(diags) {
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+// runReplay replays the recording in the given file and verifies the replay
+// matches it. It returns the process exit code, which is non-zero if the
+// recording cannot be read or the replay diverges from it.
+func runReplay(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("usage: alien-invasion-sim replay <RECORDING_FILE>")
+		return 2
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Printf("failed to open recording: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	rec, err := simulation.ReadRecording(file)
+	if err != nil {
+		fmt.Printf("failed to read recording: %v\n", err)
+		return 1
+	}
+
+	if _, err := simulation.Replay(rec); err != nil {
+		fmt.Printf("replay failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("replay of %d moves matches recording\n", len(rec.Moves))
+	return 0
+}
+
+// runBatch runs a batch of independent simulations of the same map in parallel
+// and writes a report of their aggregated outcomes. It returns the process exit
+// code, which is non-zero if the batch could not be run.
+func runBatch(args []string) int {
+	var (
+		strategySpec string
+		alienSpecs   string
+		resolverSpec string
+		worldCfg     = world.DefaultConfig()
+		batchCfg     = batch.Config{Simulation: simulation.DefaultConfig()}
+	)
+
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	mapFile := flags.String("map", "", "file containing the map definition")
+	outFile := flags.String("out", "", "optional output file to write the report to (default stdout)")
+	outFormat := flags.String("format", "auto", "format of the report: auto, csv or json")
+	inFormat := flags.String("in-format", "auto", "format of the map definition: auto, text or json")
+	strictness := flags.String("strictness", "lenient", "road consistency enforcement: lenient, strict or repair")
+	rosterFile := flags.String("roster", "", "optional file containing the attributes of aliens and the species to seed them from")
+
+	flags.UintVar(&batchCfg.Runs, "runs", 100, "number of simulations to run")
+	flags.UintVar(&batchCfg.Aliens, "n", 0, "number of aliens to use in every simulation (optional if the map places aliens)")
+	flags.Int64Var(&batchCfg.Seed, "seed", 1, "seed of the first simulation, every subsequent simulation uses the next seed")
+	flags.IntVar(&batchCfg.Workers, "workers", 0, "number of simulations to run in parallel (default one per CPU)")
+	flags.Uint64Var(&batchCfg.Budget.MaxTicks, "max-ticks", 0, "optional maximum number of ticks before a simulation is stopped")
+	registerRuleFlags(flags, &worldCfg, &batchCfg.Simulation, &resolverSpec)
+	registerStrategyFlags(flags, &strategySpec, &alienSpecs)
+
+	flags.Usage = func() {
+		fmt.Println("usage: alien-invasion-sim batch --map=<MAP_FILE> --runs=<RUNS> --n=<NUMBER_OF_ALIENS> [--out=<REPORT_FILE>]")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	if len(*mapFile) == 0 {
+		fmt.Println("invalid map definition: no file specified")
+		flags.Usage()
+		return 2
+	}
+
+	mode, err := mapfile.ParseStrictness(*strictness)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	inFmt, err := mapfile.ParseFormat(*inFormat)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if err := worldCfg.Validate(); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	strategy, alienStrategies, err := parseStrategies(strategySpec, alienSpecs)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	resolver, err := world.ParseFightResolver(resolverSpec)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	writeReport := batch.Report.WriteJSON
+
+	switch {
+	case *outFormat == "csv" || (*outFormat == "auto" && strings.EqualFold(filepath.Ext(*outFile), ".csv")):
+		writeReport = batch.Report.WriteCSV
+
+	case *outFormat != "json" && *outFormat != "auto":
+		fmt.Printf("unknown report format %q: must be one of auto, csv or json\n", *outFormat)
+		return 2
+	}
+
+	// Load the map only once. Every run starts from an independent clone of
+	// it that draws its randomness from the run's own seed.
+	template, diags, err := mapfile.LoadFile(*mapFile, inFmt, rng.NewRand(batchCfg.Seed), worldCfg, mode)
+	for _, d := range diags {
+		if d.Severity != mapfile.SeverityError {
+			log.Println(d)
+		}
+	}
+
+	if err != nil {
+		log.Printf("failed to build map from file: %v", err)
+		return 1
+	}
+
+	setStrategies(template, strategy, alienStrategies)
+	template.SetFightResolver(resolver)
+
+	if len(*rosterFile) != 0 {
+		if err := setRoster(template, *rosterFile); err != nil {
+			log.Printf("failed to load roster: %v", err)
+			return 1
+		}
+	}
+
+	newMap := func(r *rng.Rand) (*world.Map, error) {
+		return template.CloneWithRand(r), nil
+	}
+
+	log.Printf("running %d simulations", batchCfg.Runs)
+
+	results, err := batch.Run(context.Background(), newMap, batchCfg)
+	if err != nil {
+		log.Printf("failed to run batch: %v", err)
+		return 1
 	}
 
-	if err := scanner.Err(); err != nil {
+	report := batch.NewReport(template.CityNames(), results)
+
+	if len(*outFile) == 0 {
+		if err := writeReport(report, os.Stdout); err != nil {
+			log.Printf("failed to write report: %v", err)
+			return 1
+		}
+
+		return 0
+	}
+
+	file, err := os.Create(*outFile)
+	if err != nil {
+		log.Printf("failed to create report file: %v", err)
+		return 1
+	}
+
+	if err := writeReport(report, file); err != nil {
+		file.Close()
+		log.Printf("failed to write report: %v", err)
+		return 1
+	}
+
+	if err := file.Close(); err != nil {
+		log.Printf("failed to write report: %v", err)
+		return 1
+	}
+
+	return 0
+}
+
+// writeRecording writes the recording of a simulation to the file at the
+// given path.
+func writeRecording(path string, recorder *simulation.Recorder) error {
+	rec, err := recorder.Recording()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := rec.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// resumeSimulation restores a simulation from the checkpoint file at the given
+// path.
+func resumeSimulation(path string) (*simulation.Simulation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cp, err := simulation.ReadCheckpoint(file)
+	if err != nil {
 		return nil, err
 	}
 
-	return worldMap, nil
+	return simulation.Restore(cp)
+}
+
+// saveCheckpoint writes a checkpoint of a stopped simulation to the file at
+// the given path, if any, so that it can be resumed later on.
+func saveCheckpoint(path string, sim *simulation.Simulation) {
+	if len(path) == 0 {
+		return
+	}
+
+	cp, err := sim.Checkpoint()
+	if err == nil {
+		err = writeCheckpoint(path, cp)
+	}
+
+	if err != nil {
+		log.Fatalf("failed to write checkpoint to file: %v", err)
+	}
+
+	log.Printf("checkpoint written at tick %d", cp.Tick)
 }
 
-// writeMapToFile writes a given world map to the file at path 'outPath'. An
-// error is returned if the file cannot be created or written to.
-func writeMapToFile(worldMap *world.Map, outPath string) error {
-	fileHandle, err := os.Create(outPath)
+// writeCheckpoint writes a checkpoint to the file at the given path. The
+// checkpoint is written to a temporary file first which then replaces the
+// file, so that an existing checkpoint is never left half written.
+func writeCheckpoint(path string, cp *simulation.Checkpoint) error {
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
 
-	defer fileHandle.Close()
+	if err := cp.Write(file); err != nil {
+		file.Close()
+		return err
+	}
 
-	writer := bufio.NewWriter(fileHandle)
-	defer fileHandle.Close()
+	if err := file.Close(); err != nil {
+		return err
+	}
 
-	for _, city := range worldMap.Cities() {
-		s := city.String()
-		if len(s) != 0 {
-			fmt.Fprintln(writer, s)
-			writer.Flush()
-		}
+	return os.Rename(tmpPath, path)
+}
+
+// writeSummary writes the summary of a simulation to the file at the given
+// path as JSON.
+func writeSummary(path string, summary simulation.Summary) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := summary.WriteJSON(file); err != nil {
+		file.Close()
+		return err
 	}
 
-	return nil
+	return file.Close()
+}
+
+func cmdErrorMsg(errMsg string) {
+	fmt.Printf("%s\n\n", errMsg)
+	fmt.Println("usage:")
+	flag.PrintDefaults()
+	os.Exit(1)
 }
@@ -0,0 +1,199 @@
+package mapfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+// Strictness reflects how reciprocal road consistency is enforced when loading
+// a map definition. A road Foo north=Bar is consistent if Bar has the
+// reciprocal road Bar south=Foo.
+type Strictness int
+
+// The set of possible strictness modes.
+const (
+	// Lenient accepts inconsistent roads as is and reports them as warnings.
+	Lenient Strictness = iota
+	// Strict rejects any inconsistent road.
+	Strict
+	// Repair inserts any missing reciprocal road, reporting each insertion as a
+	// note. Contradictory roads that cannot be repaired are rejected.
+	Repair
+)
+
+// ParseStrictness returns the Strictness for the given name (lenient, strict
+// or repair). An error is returned if the name is unknown.
+func ParseStrictness(name string) (Strictness, error) {
+	switch strings.ToLower(name) {
+	case "lenient":
+		return Lenient, nil
+	case "strict":
+		return Strict, nil
+	case "repair":
+		return Repair, nil
+	default:
+		return Lenient, fmt.Errorf("unknown strictness %q: expected one of lenient, strict, repair", name)
+	}
+}
+
+// String implements the Stringer interface.
+func (s Strictness) String() string {
+	switch s {
+	case Lenient:
+		return "lenient"
+	case Strict:
+		return "strict"
+	case Repair:
+		return "repair"
+	default:
+		return "unknown"
+	}
+}
+
+// Reconcile checks every road in the definition for a reciprocal road in the
+// opposite direction and enforces consistency according to the given
+// strictness. Roads are evaluated in the order they are defined and two kinds
+// of inconsistencies are detected:
+//
+// 1. A missing reciprocal road, e.g. Foo north=Bar without Bar south=Foo. In
+// Repair mode the reciprocal road is inserted, unless Bar already has another
+// road to the south in which case the road cannot be repaired.
+// 2. A contradictory road, e.g. Foo north=Bar and Bar north=Foo. Such roads can
+// never be repaired as it is unknown which of the two is correct.
+//
+// The definition is modified in place when repairing and the resulting
+// diagnostics are returned.
+func (d *Definition) Reconcile(strictness Strictness) []Diagnostic {
+	var diags []Diagnostic
+
+	report := func(sev Severity, link LinkDefinition, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{
+			File:     d.File,
+			Line:     link.Line,
+			Column:   link.Column,
+			Severity: sev,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Inconsistencies are errors in every mode but Lenient.
+	sev := SeverityError
+	if strictness == Lenient {
+		sev = SeverityWarning
+	}
+
+	index := make(map[string]int, len(d.Cities))
+	for i, city := range d.Cities {
+		index[city.Name] = i
+	}
+
+	reported := make(map[string]bool)
+
+	// Note: The list of cities may grow while iterating when repairing roads
+	// that lead to cities without a definition of their own.
+	for i := 0; i < len(d.Cities); i++ {
+		for _, link := range d.Cities[i].Links {
+			cityName := d.Cities[i].Name
+			oppDir := world.OppositeDirection(link.Direction)
+			road := fmt.Sprintf("%s %s=%s", cityName, link.Direction, link.City)
+
+			j, defined := index[link.City]
+			if !defined {
+				j = -1
+			}
+
+			recip, contradiction := d.findReciprocal(j, oppDir, cityName)
+
+			switch {
+			case recip != nil && recip.City == cityName:
+				continue
+
+			case contradiction != nil:
+				key := pairKey(cityName, link.City)
+				if !reported[key] {
+					reported[key] = true
+					report(
+						sev, link, "road %s contradicts road %s %s=%s%s",
+						road, link.City, contradiction.Direction, cityName, origin(*contradiction),
+					)
+				}
+
+			case recip != nil:
+				report(
+					sev, link, "road %s has no reciprocal road: %s %s already leads to %s",
+					road, link.City, oppDir, recip.City,
+				)
+
+			case strictness != Repair:
+				report(sev, link, "road %s has no reciprocal road %s %s=%s", road, link.City, oppDir, cityName)
+
+			default:
+				if j < 0 {
+					j = len(d.Cities)
+					index[link.City] = j
+					d.Cities = append(d.Cities, CityDefinition{Name: link.City})
+				}
+
+				d.Cities[j].Links = append(d.Cities[j].Links, LinkDefinition{
+					Direction: oppDir,
+					City:      cityName,
+					Added:     true,
+				})
+
+				report(SeverityNote, link, "added reciprocal road %s %s=%s for road %s", link.City, oppDir, cityName, road)
+			}
+		}
+	}
+
+	return diags
+}
+
+// findReciprocal looks up the road of the city at index i in the direction
+// dir. It also returns any road of the city that leads back to cityName in a
+// different direction, which contradicts the road being checked. A negative
+// index reflects a city without a definition.
+func (d *Definition) findReciprocal(i int, dir, cityName string) (recip, contradiction *LinkDefinition) {
+	if i < 0 {
+		return nil, nil
+	}
+
+	links := d.Cities[i].Links
+	for k := range links {
+		switch {
+		case links[k].Direction == dir:
+			recip = &links[k]
+
+		case links[k].City == cityName && contradiction == nil:
+			contradiction = &links[k]
+		}
+	}
+
+	if recip != nil && recip.City == cityName {
+		return recip, nil
+	}
+
+	return recip, contradiction
+}
+
+// origin describes where a road was defined, if known.
+func origin(link LinkDefinition) string {
+	switch {
+	case link.Added:
+		return " (added while repairing)"
+	case link.Line > 0:
+		return fmt.Sprintf(" on line %d", link.Line)
+	default:
+		return ""
+	}
+}
+
+// pairKey returns a key uniquely identifying an unordered pair of cities.
+func pairKey(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+
+	return a + "\x00" + b
+}
@@ -0,0 +1,100 @@
+package mapfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity reflects how severe a problem found in a map definition is. Errors
+// prevent a map from being loaded while warnings are merely reported. Notes
+// record changes made to a definition when repairing it.
+type Severity int
+
+// The set of possible diagnostic severities.
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+	SeverityNote
+)
+
+// String implements the Stringer interface.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityNote:
+		return "note"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic reflects a single problem found in a map definition. Line and
+// Column are one-based and point to the offending token.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+// String implements the Stringer interface. The format follows the common
+// file:line:column convention understood by most editors. The position is
+// omitted if it is unknown, e.g. for JSON map definitions.
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// ValidationError is returned when a map definition contains at least one
+// diagnostic with SeverityError. It contains every error found.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Diagnostics))
+
+	for i, d := range e.Diagnostics {
+		msgs[i] = d.String()
+	}
+
+	return fmt.Sprintf("invalid map definition:\n%s", strings.Join(msgs, "\n"))
+}
+
+// HasErrors returns a boolean on whether or not any of the given diagnostics
+// is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// errorsOf returns a ValidationError containing all the error diagnostics of
+// the given list or nil if there are none.
+func errorsOf(diags []Diagnostic) error {
+	var errs []Diagnostic
+
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Diagnostics: errs}
+}
@@ -0,0 +1,78 @@
+package mapfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+// WriteDOT writes a world map to the given writer as a Graphviz DOT directed
+// graph. Cities are rendered as nodes and roads as directed edges labelled
+// with their direction. Cities occupied by aliens are highlighted and list
+// their occupants, while destroyed cities are greyed out and list the aliens
+// that destroyed them. An error is returned if writing fails.
+func WriteDOT(w io.Writer, worldMap *world.Map) error {
+	writer := bufio.NewWriter(w)
+
+	fmt.Fprintln(writer, "digraph world {")
+	fmt.Fprintln(writer, "  node [shape=ellipse];")
+
+	for _, city := range worldMap.Cities() {
+		aliens := city.AlienNames()
+
+		if len(aliens) == 0 {
+			fmt.Fprintf(writer, "  %s;\n", dotQuote(city.Name()))
+			continue
+		}
+
+		fmt.Fprintf(
+			writer, "  %s [label=\"%s\\n%s\", style=filled, fillcolor=orange];\n",
+			dotQuote(city.Name()), dotEscape(city.Name()), dotEscape(strings.Join(aliens, ", ")),
+		)
+	}
+
+	for _, d := range worldMap.DestroyedCities() {
+		fmt.Fprintf(
+			writer, "  %s [label=\"%s\\ndestroyed by %s\", style=\"filled,dashed\", "+
+				"fillcolor=lightgrey, color=grey, fontcolor=grey40];\n",
+			dotQuote(d.City), dotEscape(d.City), dotEscape(strings.Join(d.Aliens, " and ")),
+		)
+	}
+
+	for _, city := range worldMap.Cities() {
+		for _, linkDir := range city.LinkDirections() {
+			linkCityName, _ := city.Link(linkDir)
+
+			fmt.Fprintf(
+				writer, "  %s -> %s [label=%s];\n",
+				dotQuote(city.Name()), dotQuote(linkCityName), dotQuote(linkDir),
+			)
+		}
+	}
+
+	if _, err := fmt.Fprintln(writer, "}"); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// WriteDOTFile writes a world map to the file at the given path as a Graphviz
+// DOT directed graph. An error is returned if the file cannot be created or
+// written to.
+func WriteDOTFile(path string, worldMap *world.Map) error {
+	return writeFile(path, worldMap, WriteDOT)
+}
+
+// dotQuote returns the given string as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return "\"" + dotEscape(s) + "\""
+}
+
+// dotEscape escapes the given string for use within a quoted DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,71 @@
+package mapfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func TestWriteDOT(t *testing.T) {
+	input := "Foo north=Bar\nBar south=Foo east=Baz\nBaz west=Bar\n"
+
+	m, _, err := Load("test.map", strings.NewReader(input), rng.NewRand(1), world.DefaultConfig(), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien1", "Foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := `digraph world {
+  node [shape=ellipse];
+  "Foo" [label="Foo\nalien1", style=filled, fillcolor=orange];
+  "Bar";
+  "Baz";
+  "Foo" -> "Bar" [label="north"];
+  "Bar" -> "Foo" [label="south"];
+  "Bar" -> "Baz" [label="east"];
+  "Baz" -> "Bar" [label="west"];
+}
+`
+
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %s, got: %s", e, buf.String())
+	}
+
+	if err := m.AddAlien("alien2", "Bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien3", "Bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.ExecuteFights()
+
+	buf.Reset()
+	if err := WriteDOT(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e = `digraph world {
+  node [shape=ellipse];
+  "Foo" [label="Foo\nalien1", style=filled, fillcolor=orange];
+  "Baz";
+  "Bar" [label="Bar\ndestroyed by alien2 and alien3", style="filled,dashed", fillcolor=lightgrey, color=grey, fontcolor=grey40];
+}
+`
+
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %s, got: %s", e, buf.String())
+	}
+}
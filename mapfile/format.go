@@ -0,0 +1,125 @@
+package mapfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+// Format reflects the format of a map definition file.
+type Format int
+
+// The set of possible map definition formats.
+const (
+	// FormatAuto determines the format from the file extension: files ending
+	// in .json are JSON while any other file is text.
+	FormatAuto Format = iota
+	// FormatText is the line based format with one city per line.
+	FormatText
+	// FormatJSON is the JSON encoding of a world.Map.
+	FormatJSON
+)
+
+// ParseFormat returns the Format for the given name (auto, text or json). An
+// error is returned if the name is unknown.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "auto":
+		return FormatAuto, nil
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatAuto, fmt.Errorf("unknown format %q: expected one of auto, text, json", name)
+	}
+}
+
+// String implements the Stringer interface.
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// resolve returns the concrete format to use for the file at the given path.
+func (f Format) resolve(path string) Format {
+	if f != FormatAuto {
+		return f
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+
+	return FormatText
+}
+
+// LoadJSON decodes a JSON map definition from the given reader into a world
+// map that draws all of its randomness from r and is bound by the given
+// configuration. Aliens listed in the definition are placed in their cities.
+// Road consistency is enforced according to the given strictness, inserting
+// any repaired roads into the map. Any warnings and notes are returned. An
+// error is returned if the definition cannot be decoded or is invalid.
+func LoadJSON(name string, rd io.Reader, r *rng.Rand, cfg world.Config, strictness Strictness) (*world.Map, []Diagnostic, error) {
+	worldMap, err := world.NewMapWithConfig(r, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := json.NewDecoder(rd).Decode(worldMap); err != nil {
+		return nil, nil, err
+	}
+
+	def := definitionOf(name, worldMap)
+
+	diags := def.Reconcile(strictness)
+	if err := errorsOf(diags); err != nil {
+		return nil, diags, err
+	}
+
+	for _, city := range def.Cities {
+		for _, link := range city.Links {
+			if !link.Added {
+				continue
+			}
+
+			if err := worldMap.AddLink(city.Name, link.Direction, link.City); err != nil {
+				return nil, diags, fmt.Errorf("failed to repair road of city %s: %v", city.Name, err)
+			}
+		}
+	}
+
+	return worldMap, diags, nil
+}
+
+// definitionOf returns the definition of an existing world map. Positions of
+// cities and roads are unknown and left empty.
+func definitionOf(name string, worldMap *world.Map) *Definition {
+	def := &Definition{File: name}
+
+	for _, city := range worldMap.Cities() {
+		cityDef := CityDefinition{Name: city.Name()}
+
+		for _, linkDir := range city.LinkDirections() {
+			linkCityName, _ := city.Link(linkDir)
+			cityDef.Links = append(cityDef.Links, LinkDefinition{Direction: linkDir, City: linkCityName})
+		}
+
+		def.Cities = append(def.Cities, cityDef)
+	}
+
+	return def
+}
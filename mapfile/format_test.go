@@ -0,0 +1,67 @@
+package mapfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func TestLoadJSON(t *testing.T) {
+	input := `{
+  "cities": [
+    {"name": "Foo", "roads": [{"direction": "north", "city": "Bar"}]},
+    {"name": "Bar"}
+  ],
+  "aliens": [{"name": "alien1", "city": "Bar"}]
+}`
+
+	_, _, err := LoadJSON("test.json", strings.NewReader(input), rng.NewRand(1), world.DefaultConfig(), Strict)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	m, diags, err := LoadJSON("test.json", strings.NewReader(input), rng.NewRand(1), world.DefaultConfig(), Repair)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diags) != 1 || diags[0].Severity != SeverityNote {
+		t.Errorf("incorrect result: expected a single note, got: %v", diags)
+	}
+
+	if m.NumAliens() != 1 {
+		t.Errorf("incorrect result: expected: %v, got: %v", 1, m.NumAliens())
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := "Foo north=Bar\nBar south=Foo\n"
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+}
+
+func TestFormatResolve(t *testing.T) {
+	testCases := []struct {
+		f    Format
+		path string
+		e    Format
+	}{
+		{FormatAuto, "world.map", FormatText},
+		{FormatAuto, "world.JSON", FormatJSON},
+		{FormatText, "world.json", FormatText},
+		{FormatJSON, "world.map", FormatJSON},
+	}
+
+	for _, tc := range testCases {
+		if r := tc.f.resolve(tc.path); r != tc.e {
+			t.Errorf("incorrect result for %s: expected: %v, got: %v", tc.path, tc.e, r)
+		}
+	}
+}
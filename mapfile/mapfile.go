@@ -0,0 +1,359 @@
+package mapfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type (
+	// Definition reflects a parsed map definition file. Cities are kept in the
+	// order in which they are defined in the file.
+	Definition struct {
+		File   string
+		Cities []CityDefinition
+	}
+
+	// CityDefinition reflects a single city definition (line) in a map
+	// definition file.
+	CityDefinition struct {
+		Name  string
+		Line  int
+		Links []LinkDefinition
+	}
+
+	// LinkDefinition reflects a single road (directional edge) of a city
+	// definition. The direction is always lower case. Added reflects a road
+	// that was not defined but inserted when repairing the definition.
+	LinkDefinition struct {
+		Direction string
+		City      string
+		Line      int
+		Column    int
+		Added     bool
+	}
+
+	// token reflects a whitespace separated token in a line along with its
+	// one-based column.
+	token struct {
+		text   string
+		column int
+	}
+
+	// parser holds the state required to parse a map definition line by line.
+	parser struct {
+		def     *Definition
+		diags   []Diagnostic
+		defined map[string]int
+	}
+)
+
+// Parse parses a map definition from the given reader. The map definition has
+// one city per line. The city name is first, followed by 1-4 directions
+// (north, south, east, or west). Each one represents a road to another city
+// that lies in that direction. The city and each of the pairs are separated by
+// a single space, and the directions are separated from their respective
+// cities with an equals (=) sign.
+//
+// Every problem found is reported as a diagnostic referencing the given file
+// name. Parsing continues past invalid lines so all problems are reported at
+// once. An error is only returned if reading from the reader fails.
+func Parse(name string, r io.Reader) (*Definition, []Diagnostic, error) {
+	p := &parser{
+		def:     &Definition{File: name},
+		defined: make(map[string]int),
+	}
+
+	// Note: We assume the line entry can fit into the scanner's buffer
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		p.parseLine(lineNum, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return p.def, p.diags, nil
+}
+
+// Validate parses a map definition from the given reader and returns every
+// problem found, including road inconsistencies according to the given
+// strictness. An error is only returned if reading from the reader fails.
+func Validate(name string, r io.Reader, strictness Strictness) ([]Diagnostic, error) {
+	def, diags, err := Parse(name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(diags, def.Reconcile(strictness)...), nil
+}
+
+// ValidateFile validates the map definition file at the given path. The
+// format of the file is determined by its extension. JSON map definitions
+// that cannot be decoded are reported as an error rather than diagnostics.
+func ValidateFile(path string, strictness Strictness) ([]Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if FormatAuto.resolve(path) == FormatJSON {
+		_, diags, err := LoadJSON(path, file, nil, world.DefaultConfig(), strictness)
+		if _, ok := err.(*ValidationError); ok {
+			err = nil
+		}
+
+		return diags, err
+	}
+
+	return Validate(path, file, strictness)
+}
+
+// Load parses a map definition from the given reader and builds a world map
+// from it that draws all of its randomness from r and is bound by the given
+// configuration. Road consistency is enforced according to the given
+// strictness. Any warnings and notes are returned. If the definition contains
+// any errors, including cities with more roads than the configuration allows,
+// a *ValidationError is returned.
+func Load(name string, rd io.Reader, r *rng.Rand, cfg world.Config, strictness Strictness) (*world.Map, []Diagnostic, error) {
+	def, diags, err := Parse(name, rd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := errorsOf(diags); err != nil {
+		return nil, diags, err
+	}
+
+	diags = append(diags, def.Reconcile(strictness)...)
+	diags = append(diags, def.checkEdges(cfg.MaxEdges)...)
+
+	if err := errorsOf(diags); err != nil {
+		return nil, diags, err
+	}
+
+	worldMap, err := def.Build(r, cfg)
+	if err != nil {
+		return nil, diags, err
+	}
+
+	return worldMap, diags, nil
+}
+
+// LoadFile loads a world map from the map definition file at the given path
+// in the given format.
+func LoadFile(path string, format Format, r *rng.Rand, cfg world.Config, strictness Strictness) (*world.Map, []Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	if format.resolve(path) == FormatJSON {
+		return LoadJSON(path, file, r, cfg, strictness)
+	}
+
+	return Load(path, file, r, cfg, strictness)
+}
+
+// Build builds a world map from the definition that draws all of its
+// randomness from r and is bound by the given configuration. It is assumed the
+// definition is valid. An error is returned if the configuration is invalid or
+// the definition violates it.
+func (d *Definition) Build(r *rng.Rand, cfg world.Config) (*world.Map, error) {
+	worldMap, err := world.NewMapWithConfig(r, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add all the defined cities first so that cities are added in the order
+	// they are defined rather than the order they are first referenced.
+	for _, city := range d.Cities {
+		worldMap.AddCity(city.Name)
+	}
+
+	for _, city := range d.Cities {
+		for _, link := range city.Links {
+			if err := worldMap.AddLink(city.Name, link.Direction, link.City); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return worldMap, nil
+}
+
+// checkEdges returns an error diagnostic for every city with more roads than
+// the given limit.
+func (d *Definition) checkEdges(maxEdges uint) []Diagnostic {
+	var diags []Diagnostic
+
+	for _, city := range d.Cities {
+		if uint(len(city.Links)) > maxEdges {
+			diags = append(diags, Diagnostic{
+				File:     d.File,
+				Line:     city.Line,
+				Column:   1,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("city %s has %d roads, exceeding the limit of %d", city.Name, len(city.Links), maxEdges),
+			})
+		}
+	}
+
+	return diags
+}
+
+// addDiagnostic records a new diagnostic for the given position.
+func (p *parser) addDiagnostic(sev Severity, line, col int, format string, args ...interface{}) {
+	p.diags = append(p.diags, Diagnostic{
+		File:     p.def.File,
+		Line:     line,
+		Column:   col,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// parseLine parses a single city definition line, recording any problems
+// found along the way. Only valid cities and roads are added to the
+// definition.
+func (p *parser) parseLine(lineNum int, line string) {
+	if len(strings.TrimSpace(line)) == 0 {
+		p.addDiagnostic(SeverityWarning, lineNum, 1, "blank line")
+		return
+	}
+
+	tokens := p.tokenize(lineNum, line)
+	cityTok := tokens[0]
+
+	if strings.Contains(cityTok.text, "=") {
+		p.addDiagnostic(SeverityError, lineNum, cityTok.column, "missing city name before road %q", cityTok.text)
+		return
+	}
+
+	city := CityDefinition{Name: cityTok.text, Line: lineNum}
+
+	prevLine, duplicate := p.defined[city.Name]
+	if duplicate {
+		p.addDiagnostic(
+			SeverityError, lineNum, cityTok.column,
+			"duplicate definition of city %q (first defined on line %d)", city.Name, prevLine,
+		)
+	} else {
+		p.defined[city.Name] = lineNum
+	}
+
+	dirColumns := make(map[string]int, world.MaxEdges)
+	linkDirs := make(map[string]string, world.MaxEdges)
+
+	for _, tok := range tokens[1:] {
+		linkTokens := strings.Split(tok.text, "=")
+		if len(linkTokens) != 2 {
+			p.addDiagnostic(SeverityError, lineNum, tok.column, "malformed road %q: expected <direction>=<city>", tok.text)
+			continue
+		}
+
+		link := LinkDefinition{
+			Direction: strings.ToLower(linkTokens[0]),
+			City:      linkTokens[1],
+			Line:      lineNum,
+			Column:    tok.column,
+		}
+		cityCol := tok.column + len(linkTokens[0]) + 1
+
+		switch {
+		case len(link.Direction) == 0:
+			p.addDiagnostic(SeverityError, lineNum, tok.column, "missing direction in road %q", tok.text)
+			continue
+
+		case !world.IsDirection(link.Direction):
+			p.addDiagnostic(
+				SeverityError, lineNum, tok.column,
+				"unknown direction %q: expected one of %s", linkTokens[0], strings.Join(world.Directions, ", "),
+			)
+			continue
+
+		case len(link.City) == 0:
+			p.addDiagnostic(SeverityError, lineNum, cityCol, "missing city name in road %q", tok.text)
+			continue
+
+		case link.City == city.Name:
+			p.addDiagnostic(SeverityError, lineNum, cityCol, "road %q leads back to city %q itself", tok.text, city.Name)
+			continue
+		}
+
+		if col, ok := dirColumns[link.Direction]; ok {
+			p.addDiagnostic(
+				SeverityError, lineNum, tok.column,
+				"duplicate direction %q (first used at column %d)", link.Direction, col,
+			)
+			continue
+		}
+
+		if dir, ok := linkDirs[link.City]; ok {
+			p.addDiagnostic(
+				SeverityWarning, lineNum, cityCol,
+				"city %q is already reachable to the %s", link.City, dir,
+			)
+		}
+
+		dirColumns[link.Direction] = tok.column
+		linkDirs[link.City] = link.Direction
+		city.Links = append(city.Links, link)
+	}
+
+	if !duplicate {
+		p.def.Cities = append(p.def.Cities, city)
+	}
+}
+
+// tokenize splits a non-blank line into whitespace separated tokens. A warning
+// is recorded for any whitespace that is not a single space between tokens.
+func (p *parser) tokenize(lineNum int, line string) []token {
+	var tokens []token
+
+	start := -1
+	for i := 0; i <= len(line); i++ {
+		isSpace := i == len(line) || isWhitespace(line[i])
+
+		switch {
+		case !isSpace && start < 0:
+			start = i
+
+		case isSpace && start >= 0:
+			tokens = append(tokens, token{text: line[start:i], column: start + 1})
+			start = -1
+		}
+
+		if i == len(line) || !isSpace {
+			continue
+		}
+
+		// Only a single space directly following a token and followed by
+		// another token is expected.
+		validSep := line[i] == ' ' && i > 0 && !isWhitespace(line[i-1]) &&
+			i+1 < len(line) && !isWhitespace(line[i+1])
+
+		if !validSep && (i == 0 || !isWhitespace(line[i-1])) {
+			p.addDiagnostic(
+				SeverityWarning, lineNum, i+1,
+				"unexpected whitespace: tokens must be separated by a single space",
+			)
+		}
+	}
+
+	return tokens
+}
+
+// isWhitespace returns a boolean on whether or not the given byte separates
+// tokens.
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
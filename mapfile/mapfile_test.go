@@ -0,0 +1,242 @@
+package mapfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type position struct {
+	line     int
+	column   int
+	severity Severity
+}
+
+func positionsOf(diags []Diagnostic) []position {
+	r := make([]position, 0, len(diags))
+
+	for _, d := range diags {
+		r = append(r, position{line: d.Line, column: d.Column, severity: d.Severity})
+	}
+
+	return r
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		e     []position
+	}{
+		{
+			name:  "valid",
+			input: "Foo north=Bar west=Baz south=Qu-ux\nBar south=Foo west=Bee\n",
+			e:     []position{},
+		},
+		{
+			name:  "isolated city",
+			input: "Foo\n",
+			e:     []position{},
+		},
+		{
+			name:  "blank line",
+			input: "Foo north=Bar\n\nBar south=Foo\n",
+			e:     []position{{2, 1, SeverityWarning}},
+		},
+		{
+			name:  "extra whitespace",
+			input: "Foo  north=Bar \n\tBar south=Foo\n",
+			e:     []position{{1, 4, SeverityWarning}, {1, 15, SeverityWarning}, {2, 1, SeverityWarning}},
+		},
+		{
+			name:  "unknown direction",
+			input: "Foo up=Bar\n",
+			e:     []position{{1, 5, SeverityError}},
+		},
+		{
+			name:  "duplicate direction",
+			input: "Foo north=Bar north=Baz\n",
+			e:     []position{{1, 15, SeverityError}},
+		},
+		{
+			name:  "self link",
+			input: "Foo north=Foo\n",
+			e:     []position{{1, 11, SeverityError}},
+		},
+		{
+			name:  "malformed road",
+			input: "Foo north\nBar south=Foo=Baz\n",
+			e:     []position{{1, 5, SeverityError}, {2, 5, SeverityError}},
+		},
+		{
+			name:  "missing names",
+			input: "Foo =Bar north=\nnorth=Foo\n",
+			e:     []position{{1, 5, SeverityError}, {1, 16, SeverityError}, {2, 1, SeverityError}},
+		},
+		{
+			name:  "duplicate city",
+			input: "Foo north=Bar\nBar south=Foo\nFoo west=Baz\n",
+			e:     []position{{3, 1, SeverityError}},
+		},
+		{
+			name:  "duplicate target",
+			input: "Foo north=Bar east=Bar\n",
+			e:     []position{{1, 20, SeverityWarning}},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, diags, err := Parse("test.map", strings.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		r := positionsOf(diags)
+		if !reflect.DeepEqual(r, tc.e) {
+			t.Errorf("%s: incorrect result: expected: %v, got: %v (%v)", tc.name, tc.e, r, diags)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		strictness Strictness
+		e          []position
+	}{
+		{
+			name:       "consistent",
+			input:      "Foo north=Bar east=Baz\nBar south=Foo\nBaz west=Foo\n",
+			strictness: Strict,
+			e:          []position{},
+		},
+		{
+			name:       "missing reciprocal lenient",
+			input:      "Foo north=Bar\nBar west=Baz\n",
+			strictness: Lenient,
+			e:          []position{{1, 5, SeverityWarning}, {2, 5, SeverityWarning}},
+		},
+		{
+			name:       "missing reciprocal strict",
+			input:      "Foo north=Bar\nBar\n",
+			strictness: Strict,
+			e:          []position{{1, 5, SeverityError}},
+		},
+		{
+			name:       "missing reciprocal repair",
+			input:      "Foo north=Bar\nBar west=Baz\n",
+			strictness: Repair,
+			e:          []position{{1, 5, SeverityNote}, {2, 5, SeverityNote}},
+		},
+		{
+			name:       "contradiction repair",
+			input:      "Foo north=Bar\nBar north=Foo\n",
+			strictness: Repair,
+			e:          []position{{1, 5, SeverityError}},
+		},
+		{
+			name:       "occupied reciprocal repair",
+			input:      "Foo north=Bar\nBar south=Baz\nBaz north=Bar\n",
+			strictness: Repair,
+			e:          []position{{1, 5, SeverityError}},
+		},
+	}
+
+	for _, tc := range testCases {
+		def, _, err := Parse("test.map", strings.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		diags := def.Reconcile(tc.strictness)
+
+		r := positionsOf(diags)
+		if !reflect.DeepEqual(r, tc.e) {
+			t.Errorf("%s: incorrect result: expected: %v, got: %v (%v)", tc.name, tc.e, r, diags)
+		}
+	}
+}
+
+func TestReconcileRepair(t *testing.T) {
+	def, _, err := Parse("test.map", strings.NewReader("Foo north=Bar\nBar west=Baz\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	def.Reconcile(Repair)
+
+	if diags := def.Reconcile(Strict); len(diags) != 0 {
+		t.Errorf("expected repaired definition to be consistent: %v", diags)
+	}
+
+	e := []CityDefinition{
+		{Name: "Foo", Line: 1, Links: []LinkDefinition{{Direction: "north", City: "Bar", Line: 1, Column: 5}}},
+		{Name: "Bar", Line: 2, Links: []LinkDefinition{
+			{Direction: "west", City: "Baz", Line: 2, Column: 5},
+			{Direction: "south", City: "Foo", Added: true},
+		}},
+		{Name: "Baz", Links: []LinkDefinition{{Direction: "east", City: "Bar", Added: true}}},
+	}
+
+	if !reflect.DeepEqual(def.Cities, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, def.Cities)
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{File: "test.map", Line: 3, Column: 7, Severity: SeverityError, Message: "oops"}
+	e := "test.map:3:7: error: oops"
+
+	if d.String() != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, d.String())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	m, diags, err := Load("test.map", strings.NewReader("Foo North=Bar\nBar south=Foo\nBaz\n"), rng.NewRand(1), world.DefaultConfig(), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+
+	if m.NumCities() != 3 {
+		t.Errorf("incorrect result: expected: %v, got: %v", 3, m.NumCities())
+	}
+
+	_, diags, err = Load("test.map", strings.NewReader("Foo up=Bar\n\nBar south=Foo\n"), rng.NewRand(1), world.DefaultConfig(), Strict)
+
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	if len(verr.Diagnostics) != 1 || len(diags) != 2 {
+		t.Errorf("incorrect result: expected: 1 error and 2 diagnostics, got: %v, %v", verr.Diagnostics, diags)
+	}
+}
+
+func TestLoadEdgeLimit(t *testing.T) {
+	cfg := world.DefaultConfig()
+	cfg.MaxEdges = 1
+
+	input := "Foo north=Bar south=Baz\nBar south=Foo\nBaz north=Foo\n"
+
+	_, _, err := Load("test.map", strings.NewReader(input), rng.NewRand(1), cfg, Strict)
+
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	e := []position{{1, 1, SeverityError}}
+	if !reflect.DeepEqual(positionsOf(verr.Diagnostics), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, positionsOf(verr.Diagnostics))
+	}
+}
@@ -0,0 +1,94 @@
+package mapfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+// Write writes a world map to the given writer in the map definition format.
+// Cities are written in the order they were defined in and each city's roads
+// are written in the order they were defined in with their directions in lower
+// case, so that a map that has not been modified and whose directions are all
+// lower case round-trips its definition exactly. Every surviving city is
+// written, including cities without any remaining roads, except for cities
+// that were never defined in their own right and are still implied by the
+// roads of other cities leading to them. An error is returned if writing
+// fails.
+func Write(w io.Writer, worldMap *world.Map) error {
+	writer := bufio.NewWriter(w)
+
+	for _, city := range worldMap.Cities() {
+		if city.Implicit() {
+			continue
+		}
+
+		if _, err := fmt.Fprintln(writer, city); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// WriteDestroyed writes every city destroyed in a world map to the given
+// writer, one per line, in the order they were destroyed along with the
+// aliens that destroyed it. An error is returned if writing fails.
+func WriteDestroyed(w io.Writer, worldMap *world.Map) error {
+	writer := bufio.NewWriter(w)
+
+	for _, d := range worldMap.DestroyedCities() {
+		if _, err := fmt.Fprintf(writer, "%s destroyed by %s\n", d.City, strings.Join(d.Aliens, " and ")); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// WriteJSON writes a world map, including the aliens occupying it, to the
+// given writer as indented JSON. An error is returned if writing fails.
+func WriteJSON(w io.Writer, worldMap *world.Map) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(worldMap)
+}
+
+// WriteFile writes a world map to the file at the given path in the given
+// format. An error is returned if the file cannot be created or written to.
+func WriteFile(path string, format Format, worldMap *world.Map) error {
+	if format.resolve(path) == FormatJSON {
+		return writeFile(path, worldMap, WriteJSON)
+	}
+
+	return writeFile(path, worldMap, Write)
+}
+
+// WriteDestroyedFile writes every city destroyed in a world map to the file at
+// the given path. An error is returned if the file cannot be created or
+// written to.
+func WriteDestroyedFile(path string, worldMap *world.Map) error {
+	return writeFile(path, worldMap, WriteDestroyed)
+}
+
+// writeFile creates the file at the given path and writes a world map to it
+// using the given write function.
+func writeFile(path string, worldMap *world.Map, write func(io.Writer, *world.Map) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file, worldMap); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,125 @@
+package mapfile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	input := "Foo north=Bar west=Baz south=Qu-ux\n" +
+		"Bar south=Foo west=Bee\n" +
+		"Qu-ux north=Foo\n" +
+		"Bee east=Bar\n" +
+		"Baz east=Foo\n"
+
+	m, _, err := Load("test.map", strings.NewReader(input), rng.NewRand(1), world.DefaultConfig(), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != input {
+		t.Errorf("incorrect result: expected: %q, got: %q", input, buf.String())
+	}
+}
+
+func TestWriteRoundTripTestMap(t *testing.T) {
+	input, err := ioutil.ReadFile("../testmap.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, _, err := LoadFile("../testmap.txt", FormatAuto, rng.NewRand(1), world.DefaultConfig(), Lenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Cities that are only referenced by roads are not written on their own.
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := strings.TrimSuffix(string(input), "\n") + "\n"
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+
+	// The same holds for a map that went through JSON.
+	buf.Reset()
+	if err := WriteJSON(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded, _, err := LoadJSON("test.json", &buf, rng.NewRand(1), world.DefaultConfig(), Lenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+
+	// A referenced city is written once no road leads to it anymore.
+	m.AddAlien("alien1", "Bar")
+	m.AddAlien("alien2", "Bar")
+	m.ExecuteFights()
+
+	buf.Reset()
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e = "Foo west=Baz south=Qu-ux\nBee\n"
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+}
+
+func TestWriteIsolatedAndDestroyed(t *testing.T) {
+	input := "Foo north=Bar\nBar south=Foo east=Baz\nBaz west=Bar\n"
+
+	m, _, err := Load("test.map", strings.NewReader(input), rng.NewRand(1), world.DefaultConfig(), Strict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Two aliens in the middle city destroy it, leaving both other cities
+	// without any roads.
+	m.SeedAliens(2)
+	m.ExecuteFights()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := "Foo\nBaz\n"
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteDestroyed(&buf, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e = "Bar destroyed by alien1 and alien2\n"
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %q, got: %q", e, buf.String())
+	}
+}
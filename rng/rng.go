@@ -0,0 +1,94 @@
+package rng
+
+import "math"
+
+// Rand implements a small seedable pseudo random number generator based on
+// the SplitMix64 algorithm. The entire state of the generator is a single
+// 64-bit word, which makes a sequence fully reproducible from its seed
+// regardless of the platform or Go release the simulation is executed on.
+//
+// Note: Rand is not safe for concurrent use.
+type Rand struct {
+	state uint64
+}
+
+// NewRand returns a reference to a new Rand seeded with the given seed.
+func NewRand(seed int64) *Rand {
+	return &Rand{state: uint64(seed)}
+}
+
+// NewRandFromState returns a reference to a new Rand that continues the
+// sequence of a generator with the given state, as returned by State.
+func NewRandFromState(state uint64) *Rand {
+	return &Rand{state: state}
+}
+
+// State returns the entire state of the generator. It allows the sequence to
+// be continued later on via NewRandFromState.
+func (r *Rand) State() uint64 {
+	return r.state
+}
+
+// Clone returns a reference to a new Rand with the same state as r. Both
+// generators produce the same sequence from then on independently.
+func (r *Rand) Clone() *Rand {
+	return &Rand{state: r.state}
+}
+
+// Uint64 returns the next pseudo random 64-bit value in the sequence.
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// Intn returns a uniformly distributed pseudo random number in [0, n). It
+// panics if n <= 0.
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn: n must be greater than zero")
+	}
+
+	return int(r.Uint64n(uint64(n)))
+}
+
+// Uint64n returns a uniformly distributed pseudo random number in [0, n). It
+// draws exactly like Intn for the same n, but allows bounds that do not fit in
+// an int on 32-bit platforms. It panics if n == 0.
+func (r *Rand) Uint64n(n uint64) uint64 {
+	if n == 0 {
+		panic("invalid argument to Uint64n: n must be greater than zero")
+	}
+
+	// Reject values from the tail of the range that would otherwise bias the
+	// result towards smaller numbers.
+	limit := math.MaxUint64 - math.MaxUint64%n
+
+	for {
+		if v := r.Uint64(); v < limit {
+			return v % n
+		}
+	}
+}
+
+// Float64 returns a uniformly distributed pseudo random number in [0.0, 1.0).
+func (r *Rand) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Perm returns a pseudo random permutation of the integers [0, n).
+func (r *Rand) Perm(n int) []int {
+	p := make([]int, n)
+
+	for i := range p {
+		j := r.Intn(i + 1)
+		p[i] = p[j]
+		p[j] = i
+	}
+
+	return p
+}
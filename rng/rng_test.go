@@ -0,0 +1,122 @@
+package rng
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRandDeterministic(t *testing.T) {
+	r1 := NewRand(42)
+	r2 := NewRand(42)
+
+	for i := 0; i < 100; i++ {
+		v1, v2 := r1.Uint64(), r2.Uint64()
+
+		if v1 != v2 {
+			t.Fatalf("incorrect result: expected: %v, got: %v", v1, v2)
+		}
+	}
+
+	r3 := NewRand(43)
+	if NewRand(42).Uint64() == r3.Uint64() {
+		t.Errorf("expected different seeds to produce different sequences")
+	}
+}
+
+func TestIntn(t *testing.T) {
+	r := NewRand(1)
+
+	for i := 0; i < 1000; i++ {
+		v := r.Intn(7)
+
+		if v < 0 || v >= 7 {
+			t.Fatalf("incorrect result: expected value in [0, 7), got: %v", v)
+		}
+	}
+}
+
+func TestUint64n(t *testing.T) {
+	r1, r2 := NewRand(1), NewRand(1)
+
+	for i := 0; i < 1000; i++ {
+		v := r1.Uint64n(7)
+
+		if v >= 7 {
+			t.Fatalf("incorrect result: expected value in [0, 7), got: %v", v)
+		}
+
+		// Both draw the same sequence for the same bound.
+		if w := r2.Intn(7); uint64(w) != v {
+			t.Fatalf("incorrect result: expected: %v, got: %v", w, v)
+		}
+	}
+
+	// Bounds beyond the range of a 32-bit int are supported.
+	if v := r1.Uint64n(1 << 40); v >= 1<<40 {
+		t.Errorf("incorrect result: expected value in [0, 2^40), got: %v", v)
+	}
+}
+
+func TestPerm(t *testing.T) {
+	r := NewRand(1)
+	p := r.Perm(10)
+
+	sort.Ints(p)
+
+	e := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	if !reflect.DeepEqual(p, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, p)
+	}
+}
+
+func TestClone(t *testing.T) {
+	r := NewRand(42)
+	r.Uint64()
+
+	c := r.Clone()
+
+	for i := 0; i < 10; i++ {
+		if a, b := r.Uint64(), c.Uint64(); a != b {
+			t.Fatalf("incorrect result: expected: %v, got: %v", a, b)
+		}
+	}
+
+	// Advancing the clone must not affect the original.
+	c.Uint64()
+
+	if r.state == c.state {
+		t.Errorf("expected clone to advance independently")
+	}
+}
+
+func TestState(t *testing.T) {
+	r := NewRand(7)
+	r.Uint64()
+
+	restored := NewRandFromState(r.State())
+
+	for i := 0; i < 10; i++ {
+		if a, b := r.Uint64(), restored.Uint64(); a != b {
+			t.Fatalf("incorrect result: expected: %v, got: %v", a, b)
+		}
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	r := NewRand(3)
+
+	var sum float64
+	for i := 0; i < 10000; i++ {
+		v := r.Float64()
+		if v < 0 || v >= 1 {
+			t.Fatalf("value out of range: %v", v)
+		}
+
+		sum += v
+	}
+
+	if mean := sum / 10000; mean < 0.48 || mean > 0.52 {
+		t.Errorf("incorrect result: expected mean close to 0.5, got: %v", mean)
+	}
+}
@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type (
+	// Checkpoint reflects the complete state of a simulation, including its
+	// world map, that allows it to be resumed later on exactly where it left
+	// off. Whether or not the simulation has terminated is not part of a
+	// checkpoint, so a simulation that was stopped, e.g. by a budget or
+	// cancellation, resumes running.
+	Checkpoint struct {
+		Config     Config          `json:"config"`
+		Tick       uint64          `json:"tick"`
+		Started    bool            `json:"started"`
+		AlienMoves map[string]uint `json:"alien_moves"`
+		Moves      map[string]uint `json:"moves"`
+		Trapped    []string        `json:"trapped"`
+		World      world.State     `json:"world"`
+	}
+
+	// CheckpointFunc reflects a function that is handed every periodic
+	// checkpoint of a running simulation, e.g. to write it to disk. If it
+	// returns an error, the run is aborted with that error.
+	CheckpointFunc func(cp *Checkpoint) error
+)
+
+// Checkpoint returns a checkpoint of the current state of the simulation. An
+// error is returned if the state of the world map cannot be captured.
+func (s *Simulation) Checkpoint() (*Checkpoint, error) {
+	state, err := s.alienMap.State()
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{
+		Config:     s.config,
+		Tick:       s.tick,
+		Started:    s.started,
+		AlienMoves: make(map[string]uint, len(s.alienMoves)),
+		Moves:      make(map[string]uint, len(s.moves)),
+		Trapped:    s.Trapped(),
+		World:      state,
+	}
+
+	for alienName, moves := range s.alienMoves {
+		cp.AlienMoves[alienName] = moves
+	}
+
+	for alienName, moves := range s.moves {
+		cp.Moves[alienName] = moves
+	}
+
+	return cp, nil
+}
+
+// CheckpointEvery registers a function that is handed a checkpoint of the
+// simulation every n ticks while it is run via Run or RunContext. A zero n
+// disables periodic checkpoints.
+func (s *Simulation) CheckpointEvery(n uint64, fn CheckpointFunc) {
+	if n == 0 {
+		fn = nil
+	}
+
+	s.checkpointEvery = n
+	s.checkpointFn = fn
+}
+
+// checkpoint hands a checkpoint of the simulation to the registered
+// checkpoint function.
+func (s *Simulation) checkpoint() error {
+	cp, err := s.Checkpoint()
+	if err != nil {
+		return err
+	}
+
+	return s.checkpointFn(cp)
+}
+
+// Restore returns a reference to a new Simulation, along with its world map,
+// restored from the given checkpoint. Subscribers are not part of a checkpoint
+// and must be subscribed again. An error is returned if the checkpoint is
+// invalid.
+func Restore(cp *Checkpoint) (*Simulation, error) {
+	if err := cp.Config.Validate(); err != nil {
+		return nil, err
+	}
+
+	alienMap, err := world.NewMapFromState(cp.World)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSimulation(alienMap)
+	s.config = cp.Config
+	s.tick = cp.Tick
+	s.started = cp.Started
+	s.alienMoves = make(map[string]uint, len(cp.AlienMoves))
+	s.moves = make(map[string]uint, len(cp.Moves))
+
+	for alienName, moves := range cp.AlienMoves {
+		if _, ok := alienMap.AlienCity(alienName); !ok {
+			return nil, fmt.Errorf("invalid checkpoint: moves of unknown alien %s", alienName)
+		}
+
+		s.alienMoves[alienName] = moves
+	}
+
+	for alienName, moves := range cp.Moves {
+		s.moves[alienName] = moves
+	}
+
+	for _, alienName := range cp.Trapped {
+		if _, ok := alienMap.AlienCity(alienName); !ok {
+			return nil, fmt.Errorf("invalid checkpoint: trapped unknown alien %s", alienName)
+		}
+
+		s.trapped[alienName] = true
+	}
+
+	return s, nil
+}
+
+// Write writes the checkpoint to the given writer as JSON.
+func (cp *Checkpoint) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(cp)
+}
+
+// ReadCheckpoint reads a JSON encoded checkpoint from the given reader.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+
+	if err := json.NewDecoder(r).Decode(cp); err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
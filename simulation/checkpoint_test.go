@@ -0,0 +1,135 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func buildCheckpointFixture(t *testing.T) *Simulation {
+	m := world.NewMap(rng.NewRand(3))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "east", "baz")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("bar", "east", "qux")
+	m.AddLink("baz", "west", "foo")
+	m.AddLink("baz", "north", "qux")
+	m.AddLink("qux", "west", "bar")
+	m.AddLink("qux", "south", "baz")
+	m.AddLink("qux", "east", "quux")
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 50})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Seed(3)
+	return s
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	expected := buildCheckpointFixture(t)
+
+	if err := expected.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := buildCheckpointFixture(t)
+
+	var checkpoints []*Checkpoint
+	s.CheckpointEvery(5, func(cp *Checkpoint) error {
+		checkpoints = append(checkpoints, cp)
+		return nil
+	})
+
+	if err := s.RunContext(context.Background(), Budget{MaxTicks: 12}); err == nil {
+		t.Fatal("expected budget to be exceeded")
+	}
+
+	if len(checkpoints) != 2 || checkpoints[1].Tick != 10 {
+		t.Fatalf("incorrect result: expected: %v checkpoints up to tick %d, got: %v", 2, 10, len(checkpoints))
+	}
+
+	var buf bytes.Buffer
+	if err := checkpoints[1].Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp, err := ReadCheckpoint(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := Restore(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := restored.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(restored.Summary(), expected.Summary()) {
+		t.Errorf("incorrect result: expected: %v, got: %v", expected.Summary(), restored.Summary())
+	}
+
+	if restored.Map().String() != expected.Map().String() {
+		t.Errorf("incorrect result: expected: %v, got: %v", expected.Map(), restored.Map())
+	}
+
+	if !reflect.DeepEqual(restored.Map().DestroyedCities(), expected.Map().DestroyedCities()) {
+		t.Errorf("incorrect result: expected: %v, got: %v", expected.Map().DestroyedCities(), restored.Map().DestroyedCities())
+	}
+}
+
+func TestRestoreTerminated(t *testing.T) {
+	s := NewSimulation(buildMapFixturePair(t))
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp, err := s.Checkpoint()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := Restore(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A simulation that could no longer continue terminates right away.
+	if err := restored.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.Reason() != ReasonAllDestroyed || restored.Tick() != s.Tick() {
+		t.Errorf("incorrect result: expected: %v at tick %d, got: %v at tick %d", ReasonAllDestroyed, s.Tick(), restored.Reason(), restored.Tick())
+	}
+}
+
+func TestRestoreUnknownAlien(t *testing.T) {
+	s := buildCheckpointFixture(t)
+
+	cp, err := s.Checkpoint()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp.AlienMoves = map[string]uint{"alien4": 1}
+	if _, err := Restore(cp); err == nil {
+		t.Error("expected error for the moves of an unknown alien")
+	}
+
+	cp.AlienMoves = nil
+	cp.Trapped = []string{"alien4"}
+	if _, err := Restore(cp); err == nil {
+		t.Error("expected error for an unknown trapped alien")
+	}
+}
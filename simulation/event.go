@@ -0,0 +1,211 @@
+package simulation
+
+import (
+	"log"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type (
+	// Event reflects something that happened during a simulation. Every event
+	// is one of the concrete event types defined in this package.
+	Event interface {
+		// Type returns the name of the event type.
+		Type() string
+	}
+
+	// AlienSeeded is emitted when an alien is placed in a city upon seeding.
+	// Its attributes are omitted if they are the default ones.
+	AlienSeeded struct {
+		Alien      string            `json:"alien"`
+		City       string            `json:"city"`
+		Attributes *world.Attributes `json:"attributes,omitempty"`
+	}
+
+	// AlienMoved is emitted when an alien moves from one city to another
+	// following the road in the given direction.
+	AlienMoved struct {
+		Alien     string `json:"alien"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Direction string `json:"direction"`
+	}
+
+	// AlienStayed is emitted when an alien chooses to stay in its city instead
+	// of moving.
+	AlienStayed struct {
+		Alien string `json:"alien"`
+		City  string `json:"city"`
+	}
+
+	// AlienBlocked is emitted in round mode when an alien attempted to move
+	// to a city that could not hold any more arriving aliens and thus remains
+	// in its city.
+	AlienBlocked struct {
+		Alien     string `json:"alien"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Direction string `json:"direction"`
+	}
+
+	// RoadFightOccurred is emitted in round mode when aliens crossing the road
+	// between two cities in opposite directions meet and fight on it. Any
+	// aliens that survived the fight are listed as survivors, along with the
+	// health they lost if injured, and remain in their city while all the
+	// others are destroyed. Both cities and the road survive.
+	RoadFightOccurred struct {
+		Cities    [2]string       `json:"cities"`
+		Aliens    []string        `json:"aliens"`
+		Survivors []string        `json:"survivors,omitempty"`
+		Injuries  map[string]uint `json:"injuries,omitempty"`
+	}
+
+	// FightOccurred is emitted when aliens occupying the same city fight. Any
+	// aliens that survived the fight are listed as survivors, along with the
+	// health they lost if injured, while all the others are destroyed.
+	FightOccurred struct {
+		City      string          `json:"city"`
+		Aliens    []string        `json:"aliens"`
+		Survivors []string        `json:"survivors,omitempty"`
+		Injuries  map[string]uint `json:"injuries,omitempty"`
+	}
+
+	// CityDestroyed is emitted when a city is destroyed by the aliens that
+	// fought in it, all of which are destroyed along with it.
+	CityDestroyed struct {
+		City   string   `json:"city"`
+		Aliens []string `json:"aliens"`
+	}
+
+	// CityDamaged is emitted when a city survives a fight of the given aliens
+	// but takes damage. Total is the damage the city has taken so far.
+	CityDamaged struct {
+		City   string   `json:"city"`
+		Aliens []string `json:"aliens"`
+		Damage uint     `json:"damage"`
+		Total  uint     `json:"total"`
+	}
+
+	// CityIsolated is emitted when a city survives a fight of the given aliens
+	// but loses all the roads leading into or out of it.
+	CityIsolated struct {
+		City   string   `json:"city"`
+		Aliens []string `json:"aliens"`
+	}
+
+	// RoadRemoved is emitted for every road removed from the map as a result
+	// of a city being destroyed or isolated.
+	RoadRemoved struct {
+		From      string `json:"from"`
+		Direction string `json:"direction"`
+		To        string `json:"to"`
+	}
+
+	// AlienRetired is emitted when an alien has moved at least the minimum
+	// number of moves and is no longer accounted for when deciding whether or
+	// not the simulation can continue.
+	AlienRetired struct {
+		Alien string `json:"alien"`
+		Moves uint   `json:"moves"`
+	}
+
+	// AlienTrapped is emitted once for every alien as soon as it is trapped in
+	// a city without any roads leading out of it, and for every remaining
+	// alien once no alien can move anymore. Trapped aliens are no longer
+	// accounted for when deciding whether or not the simulation can continue.
+	AlienTrapped struct {
+		Alien string `json:"alien"`
+		City  string `json:"city"`
+	}
+
+	// SimulationEnded is emitted once when the simulation terminates.
+	SimulationEnded struct {
+		Reason Reason `json:"reason"`
+	}
+
+	// Subscriber reflects an interface that must be implemented in order to
+	// receive the events of a simulation. Events are delivered synchronously
+	// in the order they happen along with the tick they happened in.
+	Subscriber interface {
+		HandleEvent(tick uint64, event Event)
+	}
+
+	// SubscriberFunc is an adapter to allow the use of ordinary functions as
+	// event subscribers.
+	SubscriberFunc func(tick uint64, event Event)
+)
+
+// Type implements the Event interface.
+func (AlienSeeded) Type() string { return "alien_seeded" }
+
+// Type implements the Event interface.
+func (AlienMoved) Type() string { return "alien_moved" }
+
+// Type implements the Event interface.
+func (AlienStayed) Type() string { return "alien_stayed" }
+
+// Type implements the Event interface.
+func (AlienBlocked) Type() string { return "alien_blocked" }
+
+// Type implements the Event interface.
+func (RoadFightOccurred) Type() string { return "road_fight_occurred" }
+
+// Type implements the Event interface.
+func (FightOccurred) Type() string { return "fight_occurred" }
+
+// Type implements the Event interface.
+func (CityDestroyed) Type() string { return "city_destroyed" }
+
+// Type implements the Event interface.
+func (CityDamaged) Type() string { return "city_damaged" }
+
+// Type implements the Event interface.
+func (CityIsolated) Type() string { return "city_isolated" }
+
+// Type implements the Event interface.
+func (RoadRemoved) Type() string { return "road_removed" }
+
+// Type implements the Event interface.
+func (AlienRetired) Type() string { return "alien_retired" }
+
+// Type implements the Event interface.
+func (AlienTrapped) Type() string { return "alien_trapped" }
+
+// Type implements the Event interface.
+func (SimulationEnded) Type() string { return "simulation_ended" }
+
+// HandleEvent implements the Subscriber interface.
+func (f SubscriberFunc) HandleEvent(tick uint64, event Event) {
+	f(tick, event)
+}
+
+// NewLogSubscriber returns a Subscriber that logs every destroyed, damaged or
+// isolated city along with the aliens that fought in it, every alien that won
+// a fight and every fight on a road to the given logger.
+func NewLogSubscriber(logger *log.Logger) Subscriber {
+	return SubscriberFunc(func(_ uint64, event Event) {
+		switch e := event.(type) {
+		case CityDestroyed:
+			logger.Printf("%s has been destroyed by %s!", e.City, strings.Join(e.Aliens, " and "))
+
+		case CityDamaged:
+			logger.Printf("%s has been damaged by %s!", e.City, strings.Join(e.Aliens, " and "))
+
+		case CityIsolated:
+			logger.Printf("%s has been cut off by %s!", e.City, strings.Join(e.Aliens, " and "))
+
+		case FightOccurred:
+			if len(e.Survivors) != 0 {
+				logger.Printf("%s won the fight in %s!", strings.Join(e.Survivors, " and "), e.City)
+			}
+
+		case RoadFightOccurred:
+			if len(e.Survivors) != 0 {
+				logger.Printf("%s won the fight on the road between %s and %s!", strings.Join(e.Survivors, " and "), e.Cities[0], e.Cities[1])
+			} else {
+				logger.Printf("%s destroyed each other on the road between %s and %s!", strings.Join(e.Aliens, " and "), e.Cities[0], e.Cities[1])
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONLinesSubscriber implements a Subscriber that writes every event as a
+// single line JSON object (JSON Lines) containing the tick the event happened
+// in, the event type and the event's fields, e.g.:
+//
+//	{"tick":3,"type":"alien_moved","alien":"alien1","from":"Foo","to":"Bar","direction":"north"}
+//
+// Events are buffered and Flush must be called once the simulation has
+// terminated.
+type JSONLinesSubscriber struct {
+	writer *bufio.Writer
+	err    error
+}
+
+// NewJSONLinesSubscriber returns a reference to a new JSONLinesSubscriber
+// writing to the given writer.
+func NewJSONLinesSubscriber(w io.Writer) *JSONLinesSubscriber {
+	return &JSONLinesSubscriber{writer: bufio.NewWriter(w)}
+}
+
+// HandleEvent implements the Subscriber interface. Once writing an event has
+// failed, all subsequent events are dropped and the error is returned from
+// Flush.
+func (s *JSONLinesSubscriber) HandleEvent(tick uint64, event Event) {
+	if s.err != nil {
+		return
+	}
+
+	fields, err := json.Marshal(event)
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	// Merge the event's fields into the object holding the tick and type.
+	line := fmt.Sprintf(`{"tick":%d,"type":%q`, tick, event.Type())
+	if len(fields) > 2 {
+		line += "," + string(fields[1:len(fields)-1])
+	}
+
+	_, s.err = fmt.Fprintln(s.writer, line+"}")
+}
+
+// Flush writes any buffered events to the underlying writer. It returns the
+// first error encountered while encoding or writing events, if any.
+func (s *JSONLinesSubscriber) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.writer.Flush()
+}
@@ -0,0 +1,69 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONLinesSubscriber(t *testing.T) {
+	var buf bytes.Buffer
+
+	sub := NewJSONLinesSubscriber(&buf)
+	sub.HandleEvent(0, AlienSeeded{Alien: "alien1", City: "foo"})
+	sub.HandleEvent(3, CityDestroyed{City: "foo", Aliens: []string{"alien1", "alien2"}})
+	sub.HandleEvent(3, SimulationEnded{Reason: ReasonAllDestroyed})
+
+	if err := sub.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := `{"tick":0,"type":"alien_seeded","alien":"alien1","city":"foo"}
+{"tick":3,"type":"city_destroyed","city":"foo","aliens":["alien1","alien2"]}
+{"tick":3,"type":"simulation_ended","reason":"all aliens destroyed"}
+`
+
+	if buf.String() != e {
+		t.Errorf("incorrect result: expected: %s, got: %s", e, buf.String())
+	}
+}
+
+func TestJSONLinesSubscriberRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := NewSimulation(buildMapFixturePair(t))
+	sub := NewJSONLinesSubscriber(&buf)
+	s.Subscribe(sub)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := sub.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("incorrect result: expected: %v lines, got: %v", 6, len(lines))
+	}
+
+	lastTick := uint64(0)
+	for _, line := range lines {
+		var obj struct {
+			Tick uint64 `json:"tick"`
+			Type string `json:"type"`
+		}
+
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("invalid JSON line %s: %v", line, err)
+		}
+
+		if obj.Tick < lastTick {
+			t.Errorf("expected ticks to be monotonically increasing: %v after %v", obj.Tick, lastTick)
+		}
+
+		lastTick = obj.Tick
+	}
+}
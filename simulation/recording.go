@@ -0,0 +1,433 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type (
+	// Recording reflects everything required to re-execute a simulation
+	// exactly: the configuration and initial map (including any aliens it
+	// places), the aliens seeded into it, every move made, the outcome of
+	// every fight on a road and in a city and the resulting final map.
+	Recording struct {
+		Config     world.Config        `json:"config"`
+		Map        json.RawMessage     `json:"map"`
+		Placements []AlienSeeded       `json:"placements"`
+		Moves      []RecordedMove      `json:"moves"`
+		Collisions []RecordedCollision `json:"collisions,omitempty"`
+		Fights     []RecordedFight     `json:"fights,omitempty"`
+		Final      json.RawMessage     `json:"final"`
+	}
+
+	// RecordedMove reflects a single recorded alien move along with the tick
+	// it was made in.
+	RecordedMove struct {
+		Tick      uint64 `json:"tick,omitempty"`
+		Alien     string `json:"alien"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Direction string `json:"direction"`
+	}
+
+	// RecordedCollision reflects aliens that met and fought on a road and the
+	// outcome of their fight along with the tick they met in.
+	RecordedCollision struct {
+		Tick      uint64          `json:"tick"`
+		Cities    [2]string       `json:"cities"`
+		Aliens    []string        `json:"aliens"`
+		Survivors []string        `json:"survivors,omitempty"`
+		Injuries  map[string]uint `json:"injuries,omitempty"`
+	}
+
+	// RecordedFight reflects the outcome of a fight in a city along with the
+	// tick it happened in.
+	RecordedFight struct {
+		Tick      uint64          `json:"tick"`
+		City      string          `json:"city"`
+		Aliens    []string        `json:"aliens"`
+		Survivors []string        `json:"survivors,omitempty"`
+		Injuries  map[string]uint `json:"injuries,omitempty"`
+		Destroyed bool            `json:"destroyed,omitempty"`
+		Damage    uint            `json:"damage,omitempty"`
+		Isolated  bool            `json:"isolated,omitempty"`
+	}
+
+	// replayResolver implements a world.FightResolver that resolves fights
+	// exactly as recorded, in the order they were recorded. Fights in cities
+	// are resolved by the fallback resolver instead, if any. The first fight
+	// that does not match the recording is kept as an error.
+	replayResolver struct {
+		fights     []RecordedFight
+		collisions []RecordedCollision
+		fallback   world.FightResolver
+		err        error
+	}
+
+	// Recorder implements a Subscriber that records a simulation. It must be
+	// created before the map is seeded and subscribed before the simulation is
+	// seeded or run.
+	Recorder struct {
+		alienMap  *world.Map
+		recording Recording
+		err       error
+	}
+
+	// DivergenceError is returned when replaying a recording does not result
+	// in the same simulation as the one recorded.
+	DivergenceError struct {
+		// Move is the index of the move the replay diverged at or -1 if the
+		// replay diverged before or after any moves were made.
+		Move   int
+		Reason string
+	}
+)
+
+// Error implements the error interface.
+func (e *DivergenceError) Error() string {
+	if e.Move < 0 {
+		return fmt.Sprintf("replay diverged from recording: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("replay diverged from recording at move %d: %s", e.Move, e.Reason)
+}
+
+// NewRecorder returns a reference to a new Recorder for the given map. The
+// current state of the map is recorded as the initial map. An error is
+// returned if the map cannot be encoded.
+func NewRecorder(alienMap *world.Map) (*Recorder, error) {
+	initial, err := json.Marshal(alienMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		alienMap: alienMap,
+		recording: Recording{
+			Config:     alienMap.Config(),
+			Map:        initial,
+			Placements: []AlienSeeded{},
+			Moves:      []RecordedMove{},
+		},
+	}, nil
+}
+
+// HandleEvent implements the Subscriber interface. Seeded aliens, moves and
+// the outcome of fights on roads and in cities are recorded and the final map
+// is recorded once the simulation has ended.
+func (r *Recorder) HandleEvent(tick uint64, event Event) {
+	switch e := event.(type) {
+	case AlienSeeded:
+		r.recording.Placements = append(r.recording.Placements, e)
+
+	case AlienMoved:
+		r.recording.Moves = append(r.recording.Moves, RecordedMove{
+			Tick:      tick,
+			Alien:     e.Alien,
+			From:      e.From,
+			To:        e.To,
+			Direction: e.Direction,
+		})
+
+	case RoadFightOccurred:
+		r.recording.Collisions = append(r.recording.Collisions, RecordedCollision{
+			Tick:      tick,
+			Cities:    e.Cities,
+			Aliens:    e.Aliens,
+			Survivors: e.Survivors,
+			Injuries:  e.Injuries,
+		})
+
+	case FightOccurred:
+		r.recording.Fights = append(r.recording.Fights, RecordedFight{
+			Tick:      tick,
+			City:      e.City,
+			Aliens:    e.Aliens,
+			Survivors: e.Survivors,
+			Injuries:  e.Injuries,
+		})
+
+	case CityDestroyed:
+		r.lastFight().Destroyed = true
+
+	case CityDamaged:
+		r.lastFight().Damage = e.Damage
+
+	case CityIsolated:
+		r.lastFight().Isolated = true
+
+	case SimulationEnded:
+		r.recording.Final, r.err = json.Marshal(r.alienMap)
+	}
+}
+
+// lastFight returns a reference to the last recorded fight. The outcome of a
+// fight is always emitted after the fight itself.
+func (r *Recorder) lastFight() *RecordedFight {
+	return &r.recording.Fights[len(r.recording.Fights)-1]
+}
+
+// Recording returns the recording of the simulation. An error is returned if
+// the final map could not be recorded.
+func (r *Recorder) Recording() (*Recording, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	rec := r.recording
+	return &rec, nil
+}
+
+// Write writes the recording to the given writer as JSON.
+func (rec *Recording) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(rec)
+}
+
+// ReadRecording reads a JSON encoded recording from the given reader.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	rec := &Recording{}
+
+	if err := json.NewDecoder(r).Decode(rec); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// Replay re-executes a recording against a fresh map built from the recorded
+// configuration and initial map. The recorded aliens are placed, every
+// recorded move and fight on a road is applied and fights are executed
+// exactly as they would have been during the recorded simulation. All the
+// moves and fights on roads recorded in the same tick, i.e. during a round,
+// are applied before fights are executed. Fights are resolved as recorded,
+// except that fights in cities are resolved with the default fight resolver if
+// the recording has no fights in cities. The resulting map is returned. A
+// *DivergenceError is returned if any move cannot be applied, leads to a
+// different city than recorded, any fight does not match the recording or the
+// resulting map does not match the recorded final map.
+func Replay(rec *Recording) (*world.Map, error) {
+	// Moves are never chosen randomly during a replay, so the seed used is
+	// irrelevant.
+	alienMap, err := world.NewMapWithConfig(rng.NewRand(0), rec.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorded configuration: %v", err)
+	}
+
+	if err := json.Unmarshal(rec.Map, alienMap); err != nil {
+		return nil, fmt.Errorf("invalid recorded map: %v", err)
+	}
+
+	for _, p := range rec.Placements {
+		if err := alienMap.AddAlien(p.Alien, p.City); err != nil {
+			return nil, &DivergenceError{Move: -1, Reason: fmt.Sprintf("failed to place alien %s: %v", p.Alien, err)}
+		}
+
+		if p.Attributes != nil {
+			if err := alienMap.SetAlienAttributes(p.Alien, *p.Attributes); err != nil {
+				return nil, fmt.Errorf("invalid recorded attributes of alien %s: %v", p.Alien, err)
+			}
+		}
+	}
+
+	resolver := &replayResolver{fights: rec.Fights, collisions: rec.Collisions}
+	if len(rec.Fights) == 0 {
+		resolver.fallback = world.DestroyAll{}
+	}
+
+	alienMap.SetFightResolver(resolver)
+
+	if err := replayFights(alienMap, resolver); err != nil {
+		return nil, err
+	}
+
+	moves, collisions := rec.Moves, rec.Collisions
+
+	for i := 0; len(moves) != 0 || len(collisions) != 0; {
+		// Apply everything recorded in the next tick. Moves recorded without
+		// a tick are applied one at a time.
+		var tick uint64
+
+		if len(moves) != 0 && (len(collisions) == 0 || moves[0].Tick <= collisions[0].Tick) {
+			tick = moves[0].Tick
+
+			n := 1
+			for tick != 0 && n < len(moves) && moves[n].Tick == tick {
+				n++
+			}
+
+			for _, rm := range moves[:n] {
+				if err := replayMove(alienMap, rm); err != nil {
+					return nil, &DivergenceError{Move: i, Reason: err.Error()}
+				}
+
+				i++
+			}
+
+			moves = moves[n:]
+		} else {
+			tick = collisions[0].Tick
+		}
+
+		for len(collisions) != 0 && collisions[0].Tick == tick {
+			c := world.Collision{Cities: collisions[0].Cities, Aliens: collisions[0].Aliens}
+
+			if err := alienMap.ApplyCollision(c); err != nil {
+				return nil, &DivergenceError{Move: -1, Reason: fmt.Sprintf("failed to apply collision in tick %d: %v", tick, err)}
+			}
+
+			collisions = collisions[1:]
+		}
+
+		if err := replayFights(alienMap, resolver); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(resolver.fights) != 0 {
+		return nil, &DivergenceError{Move: -1, Reason: fmt.Sprintf("%d recorded fights did not happen", len(resolver.fights))}
+	}
+
+	final, err := json.Marshal(alienMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var expected bytes.Buffer
+	if err := json.Compact(&expected, rec.Final); err != nil {
+		return nil, fmt.Errorf("invalid recorded final map: %v", err)
+	}
+
+	if !bytes.Equal(final, expected.Bytes()) {
+		return nil, &DivergenceError{
+			Move:   -1,
+			Reason: fmt.Sprintf("final map %s does not match recorded final map %s", final, expected.Bytes()),
+		}
+	}
+
+	return alienMap, nil
+}
+
+// replayMove applies a recorded move to the given map. An error is returned if
+// the move cannot be applied or leads to a different city than recorded.
+func replayMove(alienMap *world.Map, rm RecordedMove) error {
+	move, err := alienMap.ApplyMove(rm.Alien, rm.Direction)
+	if err != nil {
+		return err
+	}
+
+	if move.From != rm.From || move.To != rm.To {
+		return fmt.Errorf(
+			"alien %s moved from %s to %s, recorded from %s to %s",
+			rm.Alien, move.From, move.To, rm.From, rm.To,
+		)
+	}
+
+	return nil
+}
+
+// replayFights executes all fights on the given map. A *DivergenceError is
+// returned if the given replay resolver found a fight that does not match the
+// recording.
+func replayFights(alienMap *world.Map, resolver *replayResolver) error {
+	alienMap.ResolveFights()
+
+	if resolver.err != nil {
+		return &DivergenceError{Move: -1, Reason: resolver.err.Error()}
+	}
+
+	return nil
+}
+
+// ResolveFight implements the world.FightResolver interface.
+func (r *replayResolver) ResolveFight(city *world.City, alienNames []string, rand *rng.Rand) world.FightOutcome {
+	destroyAll := world.FightOutcome{Killed: alienNames, DestroyCity: true}
+
+	if r.err != nil {
+		return destroyAll
+	}
+
+	if road := city.Road(); road != nil {
+		return r.resolveRoadFight(road, alienNames)
+	}
+
+	if r.fallback != nil {
+		return r.fallback.ResolveFight(city, alienNames, rand)
+	}
+
+	if len(r.fights) == 0 {
+		r.err = fmt.Errorf("unrecorded fight of %s in %s", strings.Join(alienNames, " and "), city.Name())
+		return destroyAll
+	}
+
+	rf := r.fights[0]
+	r.fights = r.fights[1:]
+
+	if rf.City != city.Name() || strings.Join(rf.Aliens, " ") != strings.Join(alienNames, " ") {
+		r.err = fmt.Errorf(
+			"fight of %s in %s, recorded fight of %s in %s",
+			strings.Join(alienNames, " and "), city.Name(), strings.Join(rf.Aliens, " and "), rf.City,
+		)
+
+		return destroyAll
+	}
+
+	return world.FightOutcome{
+		Killed:       killedOf(alienNames, rf.Survivors),
+		DestroyCity:  rf.Destroyed,
+		Damage:       rf.Damage,
+		DestroyRoads: rf.Isolated,
+		Injuries:     rf.Injuries,
+	}
+}
+
+// resolveRoadFight resolves a fight of the given aliens on the road between
+// the given cities as recorded.
+func (r *replayResolver) resolveRoadFight(road, alienNames []string) world.FightOutcome {
+	destroyAll := world.FightOutcome{Killed: alienNames, DestroyCity: true}
+
+	if len(r.collisions) == 0 {
+		r.err = fmt.Errorf("unrecorded fight of %s on the road between %s and %s", strings.Join(alienNames, " and "), road[0], road[1])
+		return destroyAll
+	}
+
+	rc := r.collisions[0]
+	r.collisions = r.collisions[1:]
+
+	if rc.Cities[0] != road[0] || rc.Cities[1] != road[1] || strings.Join(rc.Aliens, " ") != strings.Join(alienNames, " ") {
+		r.err = fmt.Errorf(
+			"fight of %s on the road between %s and %s, recorded fight of %s on the road between %s and %s",
+			strings.Join(alienNames, " and "), road[0], road[1], strings.Join(rc.Aliens, " and "), rc.Cities[0], rc.Cities[1],
+		)
+
+		return destroyAll
+	}
+
+	return world.FightOutcome{Killed: killedOf(alienNames, rc.Survivors), Injuries: rc.Injuries}
+}
+
+// killedOf returns the names of the given aliens that are not among the given
+// survivors.
+func killedOf(alienNames, survivors []string) []string {
+	survived := make(map[string]bool, len(survivors))
+	for _, alienName := range survivors {
+		survived[alienName] = true
+	}
+
+	var killed []string
+	for _, alienName := range alienNames {
+		if !survived[alienName] {
+			killed = append(killed, alienName)
+		}
+	}
+
+	return killed
+}
+
+// String implements the world.FightResolver interface.
+func (r *replayResolver) String() string { return "replay" }
@@ -0,0 +1,300 @@
+package simulation
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func buildRecordingFixture(t *testing.T) *Recording {
+	m := world.NewMap(rng.NewRand(7))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "east", "baz")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("bar", "east", "qux")
+	m.AddLink("baz", "west", "foo")
+	m.AddLink("baz", "north", "qux")
+	m.AddLink("qux", "west", "bar")
+	m.AddLink("qux", "south", "baz")
+
+	rec, err := NewRecorder(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := NewSimulation(m)
+	s.Subscribe(rec)
+	s.Seed(3)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recording, err := rec.Recording()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return recording
+}
+
+func TestReplay(t *testing.T) {
+	rec := buildRecordingFixture(t)
+
+	if len(rec.Placements) != 3 {
+		t.Fatalf("incorrect result: expected: %v, got: %v", 3, len(rec.Placements))
+	}
+
+	var buf bytes.Buffer
+	if err := rec.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	read, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(read.Moves, rec.Moves) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", rec.Moves, read.Moves)
+	}
+
+	if _, err := Replay(read); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	rec := buildRecordingFixture(t)
+
+	if len(rec.Moves) == 0 {
+		t.Fatal("expected recording to contain moves")
+	}
+
+	tampered := *rec
+	tampered.Moves = append([]RecordedMove{}, rec.Moves...)
+	tampered.Moves[0].To = "nowhere"
+
+	_, err := Replay(&tampered)
+	if de, ok := err.(*DivergenceError); !ok || de.Move != 0 {
+		t.Errorf("expected divergence at move 0: got: %v", err)
+	}
+
+	tampered = *rec
+	tampered.Final = []byte(`{"cities":[],"aliens":[]}`)
+
+	_, err = Replay(&tampered)
+	if de, ok := err.(*DivergenceError); !ok || de.Move != -1 {
+		t.Errorf("expected divergence of final map: got: %v", err)
+	}
+}
+
+// buildGrid builds a 3x3 grid of cities with roads in both directions in the
+// given empty map.
+func buildGrid(m *world.Map) {
+	cities := [3][3]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g", "h", "i"}}
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			if c < 2 {
+				m.AddLink(cities[r][c], "east", cities[r][c+1])
+				m.AddLink(cities[r][c+1], "west", cities[r][c])
+			}
+
+			if r < 2 {
+				m.AddLink(cities[r][c], "south", cities[r+1][c])
+				m.AddLink(cities[r+1][c], "north", cities[r][c])
+			}
+		}
+	}
+}
+
+// buildGridRecording records a simulation of a 3x3 grid of cities, built in
+// the given empty map and occupied by five aliens, in the given mode.
+func buildGridRecording(t *testing.T, m *world.Map, mode Mode) *Recording {
+	buildGrid(m)
+
+	for i, cityName := range []string{"a", "c", "e", "g", "i"} {
+		if err := m.AddAlien(fmt.Sprintf("alien%d", i+1), cityName); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	rec, err := NewRecorder(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 20, Mode: mode})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s.Subscribe(rec)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recording, err := rec.Recording()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return recording
+}
+
+func TestReplayRounds(t *testing.T) {
+	var collisions int
+
+	for seed := int64(1); seed <= 20; seed++ {
+		recording := buildGridRecording(t, world.NewMap(rng.NewRand(seed)), ModeRound)
+
+		collisions += len(recording.Collisions)
+
+		if _, err := Replay(recording); err != nil {
+			t.Errorf("unexpected error for seed %d: %v", seed, err)
+		}
+	}
+
+	if collisions == 0 {
+		t.Error("expected at least one recorded collision")
+	}
+}
+
+func TestReplayWithoutTicks(t *testing.T) {
+	rec := buildRecordingFixture(t)
+
+	// Recordings made before moves carried their tick are replayed one move
+	// at a time.
+	for i := range rec.Moves {
+		rec.Moves[i].Tick = 0
+	}
+
+	if _, err := Replay(rec); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReplayFightResolvers(t *testing.T) {
+	for _, resolver := range []world.FightResolver{world.Winner{P: 0.5}, world.CityDamage{Limit: 2}, world.RoadsOnly{}} {
+		var survived bool
+
+		for seed := int64(1); seed <= 20; seed++ {
+			m := world.NewMap(rng.NewRand(seed))
+			m.SetFightResolver(resolver)
+
+			for _, mode := range []Mode{ModeSequential, ModeRound} {
+				recording := buildGridRecording(t, m.Clone(), mode)
+
+				if _, err := Replay(recording); err != nil {
+					t.Errorf("unexpected error for %s in %s mode with seed %d: %v", resolver, mode, seed, err)
+				}
+
+				for _, f := range recording.Fights {
+					survived = survived || !f.Destroyed
+				}
+			}
+		}
+
+		if !survived {
+			t.Errorf("expected at least one city to survive a fight with %s", resolver)
+		}
+	}
+}
+
+func TestReplayFightDivergence(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+	m.SetFightResolver(world.Winner{P: 1})
+
+	rec := buildGridRecording(t, m, ModeSequential)
+	if len(rec.Fights) == 0 || len(rec.Fights[0].Survivors) != 1 {
+		t.Fatalf("expected a recorded fight with a single survivor: %v", rec.Fights)
+	}
+
+	// A different winner leads to a different map.
+	tampered := *rec
+	tampered.Fights = append([]RecordedFight{}, rec.Fights...)
+	tampered.Fights[0].Survivors = nil
+
+	if _, err := Replay(&tampered); err == nil {
+		t.Error("expected divergence for tampered fight")
+	}
+
+	// Recordings made before fight outcomes were recorded are replayed with
+	// the default fight resolver.
+	tampered.Fights = nil
+
+	if _, err := Replay(&tampered); err == nil {
+		t.Error("expected divergence for missing fights")
+	}
+
+	rec = buildRecordingFixture(t)
+	rec.Fights = nil
+
+	if _, err := Replay(rec); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReplayAttributes(t *testing.T) {
+	var injured bool
+
+	for seed := int64(1); seed <= 20; seed++ {
+		m := world.NewMap(rng.NewRand(seed))
+		m.SetFightResolver(world.Strength{})
+		buildGrid(m)
+
+		err := m.SetRoster(world.Roster{Species: []world.Species{
+			{Name: "grey", Weight: 1, Strength: world.Range{Min: 1, Max: 3}, Health: world.Range{Min: 2, Max: 6}},
+			{Name: "zerg", Weight: 1, Speed: world.Range{Min: 1, Max: 3}, Health: world.Range{Min: 1, Max: 4}},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rec, err := NewRecorder(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 20})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s.Subscribe(rec)
+		s.Seed(6)
+
+		if err := s.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		recording, err := rec.Recording()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, p := range recording.Placements {
+			if p.Attributes == nil || len(p.Attributes.Species) == 0 {
+				t.Fatalf("expected attributes of placed alien %s", p.Alien)
+			}
+		}
+
+		for _, f := range recording.Fights {
+			injured = injured || len(f.Injuries) != 0
+		}
+
+		if _, err := Replay(recording); err != nil {
+			t.Errorf("unexpected error for seed %d: %v", seed, err)
+		}
+	}
+
+	if !injured {
+		t.Error("expected at least one alien to be injured in a fight")
+	}
+}
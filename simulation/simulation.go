@@ -1,76 +1,618 @@
 package simulation
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/alexanderbez/alien-invasion/world"
 )
 
 const (
-	minAlienMoves = 10000
+	// MinAlienMoves reflects the default minimum number of moves every alien
+	// must make before a simulation terminates.
+	MinAlienMoves = 10000
+)
+
+// Reason reflects why a simulation terminated.
+type Reason string
+
+// The set of possible termination reasons.
+const (
+	ReasonAllDestroyed Reason = "all aliens destroyed"
+	ReasonMovesReached Reason = "all aliens reached the minimum number of moves"
+	ReasonAllTrapped   Reason = "all remaining aliens trapped"
+	ReasonAborted      Reason = "aborted"
+	ReasonCanceled     Reason = "canceled"
+	ReasonBudget       Reason = "budget exceeded"
+)
+
+// Mode reflects how aliens move during a simulation.
+type Mode string
+
+// The set of possible movement modes.
+const (
+	// ModeSequential moves a single alien per tick and executes fights after
+	// every move.
+	ModeSequential Mode = "sequential"
+	// ModeRound moves every alien simultaneously per tick and executes fights
+	// once all moves have been made.
+	ModeRound Mode = "round"
 )
 
-// Simulation reflects a simulation of an alien invasion on a given world map.
-type Simulation struct {
-	alienMap   *world.Map
-	alienMoves map[string]uint
+// BudgetKind reflects a kind of budget a run can be limited by.
+type BudgetKind string
+
+// The set of possible budget kinds.
+const (
+	BudgetTicks    BudgetKind = "max ticks"
+	BudgetDuration BudgetKind = "max duration"
+)
+
+// ErrDone is returned when stepping a simulation that has already terminated.
+var ErrDone = errors.New("simulation has already terminated")
+
+type (
+	// Simulation reflects a simulation of an alien invasion on a given world
+	// map. Everything that happens during the simulation is emitted as an
+	// event to all subscribers.
+	Simulation struct {
+		alienMap    *world.Map
+		alienMoves  map[string]uint
+		moves       map[string]uint
+		subscribers []Subscriber
+		tick        uint64
+		started     bool
+		reason      Reason
+		config      Config
+		trapped     map[string]bool
+
+		checkpointEvery uint64
+		checkpointFn    CheckpointFunc
+	}
+
+	// Config reflects the rules a simulation is bound by.
+	Config struct {
+		// MinAlienMoves is the minimum number of moves every alien must make
+		// before the simulation terminates, unless it is destroyed first.
+		MinAlienMoves uint `json:"min_alien_moves"`
+		// Mode is how aliens move. An empty mode is equivalent to
+		// ModeSequential.
+		Mode Mode `json:"mode,omitempty"`
+	}
+
+	// Budget reflects the limits a run of a simulation is bound by. A zero
+	// value for any limit means it is unlimited.
+	Budget struct {
+		// MaxTicks is the maximum tick the simulation may reach, i.e. the
+		// maximum total number of moves, including aliens staying in their
+		// cities.
+		MaxTicks uint64
+		// MaxDuration is the maximum wall time a single run may take.
+		MaxDuration time.Duration
+	}
+
+	// BudgetExceededError is returned when a run is stopped because it
+	// exceeded one of its budgets. The map reflects the state of the
+	// simulation at the tick it was stopped in.
+	BudgetExceededError struct {
+		Kind BudgetKind
+		Tick uint64
+	}
+
+	// StepResult reflects the outcome of a single step of a simulation.
+	StepResult struct {
+		// Tick is the tick the step was executed in. The initial step, which
+		// only executes the fights resulting from the initial placement of
+		// aliens, is executed in tick zero.
+		Tick uint64
+		// Moved is true if an alien was moved during the step and Stayed is
+		// true if an alien chose to stay in its city instead, in which case
+		// Move reflects the move made. In round mode, Moved is true if any
+		// alien was moved while Stayed and Move are unused.
+		Moved  bool
+		Stayed bool
+		Move   world.Move
+		// Moves reflects all the moves made during the step in alien name
+		// order.
+		Moves []world.Move
+		// Collisions reflects the aliens that met and fought on a road during
+		// the step. Collisions only happen in round mode.
+		Collisions []world.Collision
+		// Fights reflects the fights on roads and in cities during the step
+		// in the order they happened.
+		Fights []world.Fight
+		// Destroyed reflects the cities destroyed during the step.
+		Destroyed []world.Destruction
+		// Retired reflects the aliens that reached the minimum number of moves
+		// during the step.
+		Retired []string
+		// Trapped reflects the aliens marked as trapped during the step.
+		Trapped []string
+	}
+)
+
+// DefaultConfig returns the default configuration of a simulation.
+func DefaultConfig() Config {
+	return Config{MinAlienMoves: MinAlienMoves, Mode: ModeSequential}
+}
+
+// Validate returns an error if the configuration is nonsensical.
+func (c Config) Validate() error {
+	if c.MinAlienMoves == 0 {
+		return errors.New("invalid minimum number of alien moves: must be greater than zero")
+	}
+
+	switch c.Mode {
+	case "", ModeSequential, ModeRound:
+		return nil
+	}
+
+	return fmt.Errorf("unknown mode %q: must be one of %s or %s", c.Mode, ModeSequential, ModeRound)
 }
 
 // NewSimulation returns a reference to a new initialized alien invasion
-// Simulation. It adds all known alien names to the map of alien moves ahead of
-// time so they can be removed efficiently once an alien has reached
-// minAlienMoves.
+// Simulation using the default configuration. It adds all known alien names to
+// the map of alien moves ahead of time so they can be removed efficiently once
+// an alien has reached the minimum number of moves.
 func NewSimulation(alienMap *world.Map) *Simulation {
 	s := &Simulation{
 		alienMap:   alienMap,
 		alienMoves: make(map[string]uint),
+		moves:      make(map[string]uint),
+		config:     DefaultConfig(),
+		trapped:    make(map[string]bool),
 	}
 
 	for _, alienName := range alienMap.AlienNames() {
 		s.alienMoves[alienName] = 0
+		s.moves[alienName] = 0
 	}
 
 	return s
 }
 
-// Run executes an alien invasion simulation. It will continue to execute
-// random alien moves and attempt to fight them to destroy cities. After each
-// single random alien move, it will track the total number of moves for that
-// given alien. The simulation will terminate when all the aliens have been
-// destroyed or each alien has moved at least 'minAlienMoves' times. An error
-// is returned if the simulation fails to move any alien during a run.
+// NewSimulationWithConfig returns a reference to a new initialized alien
+// invasion Simulation just like NewSimulation, but using the given
+// configuration. An error is returned if the configuration is invalid.
+func NewSimulationWithConfig(alienMap *world.Map, cfg Config) (*Simulation, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := NewSimulation(alienMap)
+	s.config = cfg
+
+	return s, nil
+}
+
+// Subscribe registers a subscriber that will receive every event emitted from
+// then on.
+func (s *Simulation) Subscribe(sub Subscriber) {
+	s.subscribers = append(s.subscribers, sub)
+}
+
+// Seed seeds the map with 'n' aliens scattered randomly throughout the map.
+// Any fights resulting from the initial placement are executed when the
+// simulation is run. An error is returned if the map cannot hold 'n' more
+// aliens.
+func (s *Simulation) Seed(n uint) error {
+	alienNames, err := s.alienMap.SeedAliens(n)
+	if err != nil {
+		return err
+	}
+
+	for _, alienName := range alienNames {
+		city, _ := s.alienMap.AlienCity(alienName)
+
+		seeded := AlienSeeded{Alien: alienName, City: city}
+		if attrs, _ := s.alienMap.AlienAttributes(alienName); attrs != world.DefaultAttributes() {
+			seeded.Attributes = &attrs
+		}
+
+		s.alienMoves[alienName] = 0
+		s.moves[alienName] = 0
+		s.emit(seeded)
+	}
+
+	return nil
+}
+
+// Error implements the error interface.
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("simulation %s budget exceeded at tick %d", e.Kind, e.Tick)
+}
+
+// Run executes an alien invasion simulation by stepping it until it
+// terminates. The simulation will terminate when all the aliens have been
+// destroyed, each alien that is not trapped has made at least the minimum
+// number of moves or no alien can move anymore. In the latter case, all the
+// remaining aliens are trapped and the simulation terminates successfully
+// with ReasonAllTrapped. An error is returned if a step fails, e.g. a movement
+// strategy chooses an invalid move, or a checkpoint cannot be written.
 func (s *Simulation) Run() error {
-	for s.canContinue() {
-		alienName, err := s.alienMap.MoveAlien()
-		if err != nil {
-			return err
+	return s.RunContext(context.Background(), Budget{})
+}
+
+// RunContext executes an alien invasion simulation just like Run, but stops in
+// between steps once the given context is done or any of the given budgets is
+// exceeded. The context's error is returned if it is done and a
+// *BudgetExceededError if a budget is exceeded. In either case the simulation
+// terminates and the map is left as it was after the last completed step.
+func (s *Simulation) RunContext(ctx context.Context, budget Budget) error {
+	start := time.Now()
+
+	for !s.Done() {
+		select {
+		case <-ctx.Done():
+			s.terminate(ReasonCanceled)
+			return ctx.Err()
+
+		default:
 		}
 
-		_, ok := s.alienMoves[alienName]
-		if ok {
-			s.alienMoves[alienName]++
+		if budget.MaxTicks != 0 && s.started && s.tick >= budget.MaxTicks {
+			s.terminate(ReasonBudget)
+			return &BudgetExceededError{Kind: BudgetTicks, Tick: s.tick}
+		}
+
+		if budget.MaxDuration != 0 && time.Since(start) >= budget.MaxDuration {
+			s.terminate(ReasonBudget)
+			return &BudgetExceededError{Kind: BudgetDuration, Tick: s.tick}
+		}
 
-			// Once an alien has moved at least 'minAlienMoves' times, we can
-			// avoid having to track/count his moves.
-			if s.alienMoves[alienName] >= minAlienMoves {
-				delete(s.alienMoves, alienName)
+		tick := s.tick
+
+		if _, err := s.Step(); err != nil {
+			return err
+		}
+
+		if s.checkpointFn != nil && s.tick != tick && !s.Done() && s.tick%s.checkpointEvery == 0 {
+			if err := s.checkpoint(); err != nil {
+				s.terminate(ReasonAborted)
+				return err
 			}
 		}
+	}
+
+	return nil
+}
 
-		s.alienMap.ExecuteFights()
+// Step advances the simulation by a single step. The initial step invokes an
+// initial series of alien fights where a search of the map (graph) is done
+// looking for cities occupied by at least the fight threshold of aliens. Every
+// subsequent step executes a single alien move chosen by its movement
+// strategy, or in round mode a round of simultaneous moves of all aliens,
+// tracks the total number of moves of the aliens that moved and attempts to
+// fight them to destroy cities. An alien that stays in its city does not make
+// a move, but the step still advances the tick. Aliens that become trapped are
+// marked as such and no longer accounted for when deciding whether or not the
+// simulation can continue. Once the simulation cannot continue, it terminates
+// as part of the step that ended it. If no alien can move, all remaining
+// aliens are marked as trapped and the simulation terminates successfully.
+// ErrDone is returned if the simulation has already terminated and an error is
+// returned, terminating the simulation, if it otherwise fails to move any
+// alien.
+func (s *Simulation) Step() (StepResult, error) {
+	if s.Done() {
+		return StepResult{}, ErrDone
 	}
 
+	var result StepResult
+
+	initial := !s.started
+
+	if initial {
+		s.started = true
+	} else if !s.canContinue() {
+		// A simulation restored from a checkpoint taken once it could no
+		// longer continue terminates without making any further moves.
+		s.finish()
+		return StepResult{Tick: s.tick}, nil
+	} else {
+		var err error
+
+		if s.config.Mode == ModeRound {
+			err = s.moveRound(&result)
+		} else {
+			err = s.moveAlien(&result)
+		}
+
+		if err == world.ErrAliensTrapped {
+			result.Tick = s.tick
+			result.Trapped = s.markTrapped(s.alienMap.AlienNames())
+
+			s.terminate(ReasonAllTrapped)
+			return result, nil
+		} else if err != nil {
+			s.terminate(ReasonAborted)
+			return StepResult{Tick: s.tick}, err
+		}
+	}
+
+	result.Tick = s.tick
+	result.Fights = s.executeFights()
+
+	for _, f := range result.Fights {
+		if f.Destroyed {
+			result.Destroyed = append(result.Destroyed, world.Destruction{City: f.City, Aliens: f.Killed, Roads: f.Roads})
+		}
+	}
+
+	// Aliens can only become trapped by moving into a city without any out
+	// links or by the out links of their city being removed in a fight, so
+	// any other alien need not be examined.
+	var candidates []string
+	if initial || len(result.Fights) != 0 {
+		candidates = s.alienMap.AlienNames()
+	} else {
+		candidates = make([]string, 0, len(result.Moves))
+
+		for _, move := range result.Moves {
+			candidates = append(candidates, move.Alien)
+		}
+	}
+
+	var trapped []string
+	for _, alienName := range candidates {
+		if s.alienMap.IsTrapped(alienName) {
+			trapped = append(trapped, alienName)
+		}
+	}
+
+	result.Trapped = s.markTrapped(trapped)
+
+	if !s.canContinue() {
+		s.finish()
+	}
+
+	return result, nil
+}
+
+// moveAlien executes a single alien move, or stay, and records it in the given
+// step result. The error of the map is returned if no alien could be moved.
+func (s *Simulation) moveAlien(result *StepResult) error {
+	move, err := s.alienMap.MoveAlien()
+	if err != nil {
+		return err
+	}
+
+	s.tick++
+	result.Move = move
+
+	if move.Stayed() {
+		s.emit(AlienStayed{Alien: move.Alien, City: move.From})
+		result.Stayed = true
+
+		return nil
+	}
+
+	s.emit(AlienMoved{Alien: move.Alien, From: move.From, To: move.To, Direction: move.Direction})
+	result.Moved = true
+	result.Moves = []world.Move{move}
+	result.Retired = s.countMove(move.Alien, result.Retired)
+
+	return nil
+}
+
+// moveRound executes a round of simultaneous alien moves and records it in the
+// given step result. The error of the map is returned if no alien could be
+// moved.
+func (s *Simulation) moveRound(result *StepResult) error {
+	round, err := s.alienMap.MoveRound()
+	if err != nil {
+		return err
+	}
+
+	s.tick++
+
+	for _, move := range round.Stays {
+		s.emit(AlienStayed{Alien: move.Alien, City: move.From})
+	}
+
+	for _, move := range round.Blocked {
+		s.emit(AlienBlocked{Alien: move.Alien, From: move.From, To: move.To, Direction: move.Direction})
+	}
+
+	for _, move := range round.Moves {
+		s.emit(AlienMoved{Alien: move.Alien, From: move.From, To: move.To, Direction: move.Direction})
+		result.Retired = s.countMove(move.Alien, result.Retired)
+	}
+
+	result.Moved = len(round.Moves) != 0
+	result.Moves = round.Moves
+	result.Collisions = round.Collisions
+
 	return nil
 }
 
+// countMove counts a move of the alien with the given name. If the alien has
+// thereby reached the minimum number of moves, it is retired and appended to
+// the given list of retired aliens, which is returned.
+func (s *Simulation) countMove(alienName string, retired []string) []string {
+	s.moves[alienName]++
+
+	totalMoves, ok := s.alienMoves[alienName]
+	if !ok {
+		return retired
+	}
+
+	s.alienMoves[alienName] = totalMoves + 1
+
+	// Once an alien has moved at least the minimum number of moves, we can
+	// avoid having to track/count his moves.
+	if totalMoves+1 >= s.config.MinAlienMoves {
+		delete(s.alienMoves, alienName)
+		s.emit(AlienRetired{Alien: alienName, Moves: totalMoves + 1})
+
+		retired = append(retired, alienName)
+	}
+
+	return retired
+}
+
+// Done returns a boolean on whether or not the simulation has terminated.
+func (s *Simulation) Done() bool {
+	return len(s.reason) != 0
+}
+
+// Reason returns the reason the simulation terminated for. It is empty if the
+// simulation has not terminated yet.
+func (s *Simulation) Reason() Reason {
+	return s.reason
+}
+
+// Trapped returns the names of the surviving aliens that have been marked as
+// trapped, if any, sorted by name.
+func (s *Simulation) Trapped() []string {
+	trapped := make([]string, 0, len(s.trapped))
+
+	for alienName := range s.trapped {
+		trapped = append(trapped, alienName)
+	}
+
+	sort.Strings(trapped)
+	return trapped
+}
+
+// Map returns the world map the simulation runs on.
+func (s *Simulation) Map() *world.Map {
+	return s.alienMap
+}
+
+// Tick returns the current tick of the simulation.
+func (s *Simulation) Tick() uint64 {
+	return s.tick
+}
+
+// markTrapped marks the given aliens as trapped and emits the resulting events.
+// Trapped aliens are no longer accounted for when deciding whether or not the
+// simulation can continue. The names of the aliens that were not already
+// marked as trapped are returned.
+func (s *Simulation) markTrapped(alienNames []string) []string {
+	var marked []string
+
+	for _, alienName := range alienNames {
+		if s.trapped[alienName] {
+			continue
+		}
+
+		city, _ := s.alienMap.AlienCity(alienName)
+
+		s.trapped[alienName] = true
+		delete(s.alienMoves, alienName)
+		s.emit(AlienTrapped{Alien: alienName, City: city})
+
+		marked = append(marked, alienName)
+	}
+
+	return marked
+}
+
+// finish terminates a simulation that cannot continue for the reason it cannot
+// continue.
+func (s *Simulation) finish() {
+	reason := ReasonMovesReached
+	if s.alienMap.NumAliens() == 0 {
+		reason = ReasonAllDestroyed
+	} else if uint(len(s.trapped)) == s.alienMap.NumAliens() {
+		reason = ReasonAllTrapped
+	}
+
+	s.terminate(reason)
+}
+
+// terminate terminates the simulation for the given reason.
+func (s *Simulation) terminate(reason Reason) {
+	s.reason = reason
+	s.emit(SimulationEnded{Reason: reason})
+}
+
+// executeFights executes all alien fights on the map, including those of
+// aliens that met on roads, and emits the resulting events. Killed aliens are
+// no longer tracked. The resulting fights are returned.
+func (s *Simulation) executeFights() []world.Fight {
+	fights := s.alienMap.ResolveFights()
+
+	for _, f := range fights {
+		for _, alienName := range f.Killed {
+			delete(s.alienMoves, alienName)
+			delete(s.trapped, alienName)
+		}
+
+		if len(f.Road) != 0 {
+			s.emit(RoadFightOccurred{
+				Cities:    [2]string{f.Road[0], f.Road[1]},
+				Aliens:    f.Aliens,
+				Survivors: survivors(f),
+				Injuries:  f.Injuries,
+			})
+
+			continue
+		}
+
+		s.emit(FightOccurred{City: f.City, Aliens: f.Aliens, Survivors: survivors(f), Injuries: f.Injuries})
+
+		switch {
+		case f.Destroyed:
+			s.emit(CityDestroyed{City: f.City, Aliens: f.Aliens})
+
+		case f.Damage != 0:
+			total, _ := s.alienMap.CityDamage(f.City)
+			s.emit(CityDamaged{City: f.City, Aliens: f.Aliens, Damage: f.Damage, Total: total})
+		}
+
+		if !f.Destroyed && len(f.Roads) != 0 {
+			s.emit(CityIsolated{City: f.City, Aliens: f.Aliens})
+		}
+
+		for _, road := range f.Roads {
+			s.emit(RoadRemoved{From: road.From, Direction: road.Direction, To: road.To})
+		}
+	}
+
+	return fights
+}
+
+// survivors returns the names of the aliens that took part in the given fight
+// and survived it.
+func survivors(f world.Fight) []string {
+	var alienNames []string
+
+	killed := make(map[string]bool, len(f.Killed))
+	for _, alienName := range f.Killed {
+		killed[alienName] = true
+	}
+
+	for _, alienName := range f.Aliens {
+		if !killed[alienName] {
+			alienNames = append(alienNames, alienName)
+		}
+	}
+
+	return alienNames
+}
+
+// emit delivers an event to all subscribers.
+func (s *Simulation) emit(event Event) {
+	for _, sub := range s.subscribers {
+		sub.HandleEvent(s.tick, event)
+	}
+}
+
 // canContinue return a boolean on whether or not a simulation can continue to
 // run. A simulation can continue if not all aliens have been destroyed or not
-// all aliens have moved at least 'minAlienMoves' times.
+// all aliens that are not trapped have made at least the minimum number of
+// moves.
 func (s *Simulation) canContinue() bool {
 	if s.alienMap.NumAliens() == 0 {
 		return false
 	}
 
 	for _, totalMoves := range s.alienMoves {
-		if totalMoves < minAlienMoves {
+		if totalMoves < s.config.MinAlienMoves {
 			return true
 		}
 	}
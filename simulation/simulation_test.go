@@ -0,0 +1,495 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+type recordedEvent struct {
+	tick  uint64
+	event Event
+}
+
+type recorder struct {
+	events []recordedEvent
+}
+
+func (r *recorder) HandleEvent(tick uint64, event Event) {
+	r.events = append(r.events, recordedEvent{tick: tick, event: event})
+}
+
+func (r *recorder) types() []string {
+	types := make([]string, len(r.events))
+
+	for i, e := range r.events {
+		types[i] = e.event.Type()
+	}
+
+	return types
+}
+
+func buildMapFixturePair(t *testing.T) *world.Map {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien2", "bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return m
+}
+
+func TestRunEvents(t *testing.T) {
+	s := NewSimulation(buildMapFixturePair(t))
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := []string{
+		"alien_moved", "fight_occurred", "city_destroyed",
+		"road_removed", "road_removed", "simulation_ended",
+	}
+
+	if !reflect.DeepEqual(r.types(), e) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", e, r.types())
+	}
+
+	for _, re := range r.events {
+		if re.tick != 1 {
+			t.Errorf("incorrect tick for %s: expected: %v, got: %v", re.event.Type(), 1, re.tick)
+		}
+	}
+
+	moved := r.events[0].event.(AlienMoved)
+	destroyed := r.events[2].event.(CityDestroyed)
+
+	if destroyed.City != moved.To {
+		t.Errorf("incorrect result: expected: %v, got: %v", moved.To, destroyed.City)
+	}
+
+	ended := r.events[5].event.(SimulationEnded)
+	if ended.Reason != ReasonAllDestroyed {
+		t.Errorf("incorrect result: expected: %v, got: %v", ReasonAllDestroyed, ended.Reason)
+	}
+}
+
+func TestSeedEvents(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	s := NewSimulation(m)
+
+	r := &recorder{}
+	s.Subscribe(r)
+	s.Seed(2)
+
+	e := []recordedEvent{
+		{tick: 0, event: AlienSeeded{Alien: "alien1", City: "foo"}},
+		{tick: 0, event: AlienSeeded{Alien: "alien2", City: "foo"}},
+	}
+
+	if !reflect.DeepEqual(r.events, e) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", e, r.events)
+	}
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	destroyed, ok := r.events[3].event.(CityDestroyed)
+	if !ok || destroyed.City != "foo" || r.events[3].tick != 0 {
+		t.Errorf("expected initial fight to destroy %s at tick 0: got: %v", "foo", r.events[3])
+	}
+}
+
+func TestLogSubscriber(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := NewSimulation(buildMapFixturePair(t))
+	s.Subscribe(NewLogSubscriber(log.New(&buf, "", 0)))
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e1 := "foo has been destroyed by alien1 and alien2!\n"
+	e2 := "bar has been destroyed by alien1 and alien2!\n"
+
+	if buf.String() != e1 && buf.String() != e2 {
+		t.Errorf("incorrect result: expected: %q or %q, got: %q", e1, e2, buf.String())
+	}
+}
+
+func TestStep(t *testing.T) {
+	s := NewSimulation(buildMapFixturePair(t))
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	result, err := s.Step()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Tick != 0 || result.Moved || len(result.Destroyed) != 0 {
+		t.Errorf("incorrect initial step result: %v", result)
+	}
+
+	if s.Done() || s.Reason() != "" {
+		t.Fatalf("expected simulation to continue: got: %v", s.Reason())
+	}
+
+	result, err = s.Step()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Tick != 1 || !result.Moved || len(result.Destroyed) != 1 {
+		t.Errorf("incorrect step result: %v", result)
+	}
+
+	if result.Destroyed[0].City != result.Move.To {
+		t.Errorf("incorrect result: expected: %v, got: %v", result.Move.To, result.Destroyed[0].City)
+	}
+
+	if !s.Done() || s.Reason() != ReasonAllDestroyed {
+		t.Errorf("incorrect result: expected: %v, got: %v", ReasonAllDestroyed, s.Reason())
+	}
+
+	if _, err := s.Step(); err != ErrDone {
+		t.Errorf("incorrect result: expected: %v, got: %v", ErrDone, err)
+	}
+
+	if n := len(r.events); r.events[n-1].event != (SimulationEnded{Reason: ReasonAllDestroyed}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", SimulationEnded{Reason: ReasonAllDestroyed}, r.events[n-1].event)
+	}
+}
+
+func TestStepStayed(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddAlien("alien1", "foo")
+	m.SetStrategy(world.Lazy{P: 0.5})
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	var moved, stayed uint64
+
+	for !s.Done() {
+		result, err := s.Step()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result.Moved {
+			moved++
+		}
+
+		if result.Stayed {
+			stayed++
+
+			if result.Move.From != result.Move.To {
+				t.Errorf("incorrect step result: %v", result)
+			}
+		}
+	}
+
+	// Staying advances the tick, but does not count as a move.
+	if moved != 5 || stayed == 0 || s.Tick() != moved+stayed {
+		t.Errorf("incorrect result: expected 5 moves in %d ticks, got: %d moves, %d stays", s.Tick(), moved, stayed)
+	}
+
+	if s.Reason() != ReasonMovesReached {
+		t.Errorf("incorrect result: expected: %v, got: %v", ReasonMovesReached, s.Reason())
+	}
+
+	var events uint64
+	for _, e := range r.events {
+		if e.event == (AlienStayed{Alien: "alien1", City: "foo"}) || e.event == (AlienStayed{Alien: "alien1", City: "bar"}) {
+			events++
+		}
+	}
+
+	if events != stayed {
+		t.Errorf("incorrect result: expected: %v, got: %v", stayed, events)
+	}
+}
+
+func TestRunContextBudget(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := NewSimulation(m)
+
+	err := s.RunContext(context.Background(), Budget{MaxTicks: 5})
+	if be, ok := err.(*BudgetExceededError); !ok || be.Kind != BudgetTicks || be.Tick != 5 {
+		t.Fatalf("expected %s budget to be exceeded at tick %d: got: %v", BudgetTicks, 5, err)
+	}
+
+	if s.Reason() != ReasonBudget {
+		t.Errorf("incorrect result: expected: %v, got: %v", ReasonBudget, s.Reason())
+	}
+
+	if m.NumAliens() != 1 {
+		t.Errorf("incorrect result: expected: %v, got: %v", 1, m.NumAliens())
+	}
+}
+
+func TestRunContextCanceled(t *testing.T) {
+	s := NewSimulation(buildMapFixturePair(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.RunContext(ctx, Budget{}); err != context.Canceled {
+		t.Fatalf("incorrect result: expected: %v, got: %v", context.Canceled, err)
+	}
+
+	if s.Reason() != ReasonCanceled || s.Tick() != 0 {
+		t.Errorf("incorrect result: expected: %v at tick 0, got: %v at tick %d", ReasonCanceled, s.Reason(), s.Tick())
+	}
+}
+
+func TestSimulationConfig(t *testing.T) {
+	if _, err := NewSimulationWithConfig(buildMapFixturePair(t), Config{}); err == nil {
+		t.Fatal("expected error for zero configuration")
+	}
+
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Reason() != ReasonMovesReached || s.Tick() != 3 {
+		t.Errorf("incorrect result: expected: %v at tick 3, got: %v at tick %d", ReasonMovesReached, s.Reason(), s.Tick())
+	}
+}
+
+func TestRunRound(t *testing.T) {
+	if _, err := NewSimulationWithConfig(buildMapFixturePair(t), Config{MinAlienMoves: 1, Mode: "teleport"}); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+
+	m := buildMapFixturePair(t)
+
+	// A third alien arrives in bar as alien2 leaves it, while alien1 and
+	// alien2 meet on the road between foo and bar.
+	m.AddLink("baz", "east", "bar")
+
+	if err := m.AddAlien("alien3", "baz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 1, Mode: ModeRound})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := []recordedEvent{
+		{tick: 1, event: AlienMoved{Alien: "alien3", From: "baz", To: "bar", Direction: "east"}},
+		{tick: 1, event: AlienRetired{Alien: "alien3", Moves: 1}},
+		{tick: 1, event: RoadFightOccurred{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien2"}}},
+		{tick: 1, event: SimulationEnded{Reason: ReasonMovesReached}},
+	}
+
+	if !reflect.DeepEqual(r.events, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r.events)
+	}
+
+	// The road fight leaves both cities and their roads intact.
+	if m.NumCities() != 3 || len(m.DestroyedCities()) != 0 {
+		t.Errorf("incorrect result: expected: %v, got: %v", "3 intact cities", m)
+	}
+}
+
+func TestRunFightResolvers(t *testing.T) {
+	aliens := []string{"alien1", "alien2"}
+
+	testCases := []struct {
+		resolver world.FightResolver
+		expected []Event
+	}{
+		{
+			world.CityDamage{Limit: 2},
+			[]Event{
+				FightOccurred{City: "foo", Aliens: aliens},
+				CityDamaged{City: "foo", Aliens: aliens, Damage: 1, Total: 1},
+			},
+		},
+		{
+			world.RoadsOnly{},
+			[]Event{
+				FightOccurred{City: "foo", Aliens: aliens},
+				CityIsolated{City: "foo", Aliens: aliens},
+				RoadRemoved{From: "bar", Direction: "south", To: "foo"},
+				RoadRemoved{From: "foo", Direction: "north", To: "bar"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		m := world.NewMap(rng.NewRand(1))
+		m.SetFightResolver(tc.resolver)
+
+		m.AddLink("foo", "north", "bar")
+		m.AddLink("bar", "south", "foo")
+
+		for _, alienName := range aliens {
+			if err := m.AddAlien(alienName, "foo"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		s := NewSimulation(m)
+
+		r := &recorder{}
+		s.Subscribe(r)
+
+		if err := s.Run(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var e []recordedEvent
+		for _, event := range append(tc.expected, SimulationEnded{Reason: ReasonAllDestroyed}) {
+			e = append(e, recordedEvent{tick: 0, event: event})
+		}
+
+		if !reflect.DeepEqual(r.events, e) {
+			t.Errorf("incorrect result for %s: expected: %v, got: %v", tc.resolver, e, r.events)
+		}
+
+		if m.NumCities() != 2 {
+			t.Errorf("expected foo to survive with %s: %v", tc.resolver, m)
+		}
+	}
+}
+
+func TestRunTrapped(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := NewSimulation(m)
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Reason() != ReasonAllTrapped || s.Tick() != 1 {
+		t.Errorf("incorrect result: expected: %v at tick 1, got: %v at tick %d", ReasonAllTrapped, s.Reason(), s.Tick())
+	}
+
+	if e := []string{"alien1"}; !reflect.DeepEqual(s.Trapped(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, s.Trapped())
+	}
+
+	e := []recordedEvent{
+		{tick: 1, event: AlienMoved{Alien: "alien1", From: "foo", To: "bar", Direction: "north"}},
+		{tick: 1, event: AlienTrapped{Alien: "alien1", City: "bar"}},
+		{tick: 1, event: SimulationEnded{Reason: ReasonAllTrapped}},
+	}
+
+	if !reflect.DeepEqual(r.events, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r.events)
+	}
+}
+
+func TestRunPartiallyTrapped(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddCity("qux")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien2", "qux"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := NewSimulationWithConfig(m, Config{MinAlienMoves: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &recorder{}
+	s.Subscribe(r)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Reason() != ReasonMovesReached || s.Tick() != 5 {
+		t.Errorf("incorrect result: expected: %v at tick 5, got: %v at tick %d", ReasonMovesReached, s.Reason(), s.Tick())
+	}
+
+	if e := []string{"alien2"}; !reflect.DeepEqual(s.Trapped(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, s.Trapped())
+	}
+
+	e := recordedEvent{tick: 0, event: AlienTrapped{Alien: "alien2", City: "qux"}}
+	if r.events[0] != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r.events[0])
+	}
+}
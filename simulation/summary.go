@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type (
+	// Summary reflects the outcome of a simulation.
+	Summary struct {
+		Reason          Reason    `json:"reason"`
+		Ticks           uint64    `json:"ticks"`
+		CitiesDestroyed uint      `json:"cities_destroyed"`
+		CitiesSurviving uint      `json:"cities_surviving"`
+		AliensKilled    uint      `json:"aliens_killed"`
+		AliensSurviving uint      `json:"aliens_surviving"`
+		TrappedAliens   []string  `json:"trapped_aliens"`
+		Moves           MoveStats `json:"moves"`
+		LargestRegion   []string  `json:"largest_region"`
+		// SurvivingSpecies reflects the number of surviving aliens per
+		// species. Aliens without a species are not accounted for.
+		SurvivingSpecies map[string]uint `json:"surviving_species,omitempty"`
+	}
+
+	// MoveStats reflects statistics of the number of moves made per alien,
+	// including aliens that have since been killed.
+	MoveStats struct {
+		Min  uint    `json:"min"`
+		Mean float64 `json:"mean"`
+		Max  uint    `json:"max"`
+	}
+)
+
+// Summary returns a summary of the simulation in its current state. The
+// largest region reflects the largest connected region of surviving cities.
+func (s *Simulation) Summary() Summary {
+	numAliens := uint(len(s.moves))
+
+	summary := Summary{
+		Reason:          s.reason,
+		Ticks:           s.tick,
+		CitiesDestroyed: uint(len(s.alienMap.DestroyedCities())),
+		CitiesSurviving: s.alienMap.NumCities(),
+		AliensKilled:    numAliens - s.alienMap.NumAliens(),
+		AliensSurviving: s.alienMap.NumAliens(),
+		TrappedAliens:   s.Trapped(),
+		LargestRegion:   s.alienMap.LargestRegion(),
+	}
+
+	if summary.LargestRegion == nil {
+		summary.LargestRegion = []string{}
+	}
+
+	for _, alienName := range s.alienMap.AlienNames() {
+		attrs, _ := s.alienMap.AlienAttributes(alienName)
+		if len(attrs.Species) == 0 {
+			continue
+		}
+
+		if summary.SurvivingSpecies == nil {
+			summary.SurvivingSpecies = make(map[string]uint)
+		}
+
+		summary.SurvivingSpecies[attrs.Species]++
+	}
+
+	if numAliens == 0 {
+		return summary
+	}
+
+	var total uint
+	summary.Moves.Min = ^uint(0)
+
+	for _, moves := range s.moves {
+		total += moves
+
+		if moves < summary.Moves.Min {
+			summary.Moves.Min = moves
+		}
+
+		if moves > summary.Moves.Max {
+			summary.Moves.Max = moves
+		}
+	}
+
+	summary.Moves.Mean = float64(total) / float64(numAliens)
+	return summary
+}
+
+// String implements the stringer interface. It returns a human readable
+// report of the summary.
+func (s Summary) String() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "termination reason: %s\n", s.Reason)
+	fmt.Fprintf(&b, "ticks: %d\n", s.Ticks)
+	fmt.Fprintf(&b, "cities: %d destroyed, %d surviving\n", s.CitiesDestroyed, s.CitiesSurviving)
+	fmt.Fprintf(&b, "aliens: %d killed, %d surviving, %d trapped", s.AliensKilled, s.AliensSurviving, len(s.TrappedAliens))
+
+	if len(s.TrappedAliens) != 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(s.TrappedAliens, ", "))
+	}
+
+	if len(s.SurvivingSpecies) != 0 {
+		species := make([]string, 0, len(s.SurvivingSpecies))
+		for name := range s.SurvivingSpecies {
+			species = append(species, name)
+		}
+
+		sort.Strings(species)
+
+		for i, name := range species {
+			species[i] = fmt.Sprintf("%s %d", name, s.SurvivingSpecies[name])
+		}
+
+		fmt.Fprintf(&b, "\nsurviving species: %s", strings.Join(species, ", "))
+	}
+
+	fmt.Fprintf(&b, "\nmoves per alien: min %d, mean %.2f, max %d\n", s.Moves.Min, s.Moves.Mean, s.Moves.Max)
+	fmt.Fprintf(&b, "largest surviving region: %d cities", len(s.LargestRegion))
+
+	if len(s.LargestRegion) != 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(s.LargestRegion, ", "))
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// WriteJSON writes the summary to the given writer as indented JSON.
+func (s Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}
@@ -0,0 +1,106 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+	"github.com/alexanderbez/alien-invasion/world"
+)
+
+func TestSummary(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("baz", "east", "qux")
+	m.AddLink("qux", "west", "baz")
+	m.AddLink("qux", "north", "quux")
+	m.AddCity("corge")
+
+	for alienName, cityName := range map[string]string{"alien1": "foo", "alien2": "bar", "alien3": "corge"} {
+		if err := m.AddAlien(alienName, cityName); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	s := NewSimulation(m)
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := Summary{
+		Reason:          ReasonAllTrapped,
+		Ticks:           1,
+		CitiesDestroyed: 1,
+		CitiesSurviving: 5,
+		AliensKilled:    2,
+		AliensSurviving: 1,
+		TrappedAliens:   []string{"alien3"},
+		Moves:           MoveStats{Min: 0, Mean: 1.0 / 3, Max: 1},
+		LargestRegion:   []string{"baz", "qux", "quux"},
+	}
+
+	summary := s.Summary()
+	if !reflect.DeepEqual(summary, e) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", e, summary)
+	}
+
+	var buf bytes.Buffer
+	if err := summary.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded Summary
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, decoded)
+	}
+
+	report := "termination reason: all remaining aliens trapped\n" +
+		"ticks: 1\n" +
+		"cities: 1 destroyed, 5 surviving\n" +
+		"aliens: 2 killed, 1 surviving, 1 trapped (alien3)\n" +
+		"moves per alien: min 0, mean 0.33, max 1\n" +
+		"largest surviving region: 3 cities (baz, qux, quux)\n"
+
+	if summary.String() != report {
+		t.Errorf("incorrect result: expected: %q, got: %q", report, summary.String())
+	}
+}
+
+func TestSummarySpecies(t *testing.T) {
+	m := world.NewMap(rng.NewRand(1))
+
+	m.AddLink("foo", "north", "bar")
+	m.AddCity("baz")
+	m.AddCity("qux")
+
+	for alienName, cityName := range map[string]string{"alien1": "foo", "alien2": "baz", "alien3": "qux", "alien4": "bar"} {
+		if err := m.AddAlien(alienName, cityName); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	m.SetAlienAttributes("alien1", world.Attributes{Species: "zerg"})
+	m.SetAlienAttributes("alien2", world.Attributes{Species: "grey"})
+	m.SetAlienAttributes("alien3", world.Attributes{Species: "grey"})
+
+	summary := NewSimulation(m).Summary()
+
+	e := map[string]uint{"grey": 2, "zerg": 1}
+	if !reflect.DeepEqual(summary.SurvivingSpecies, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, summary.SurvivingSpecies)
+	}
+
+	if !strings.Contains(summary.String(), "\nsurviving species: grey 2, zerg 1\n") {
+		t.Errorf("expected surviving species in report: %q", summary.String())
+	}
+}
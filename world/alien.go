@@ -1,8 +1,24 @@
 package world
 
-// Alien implements an entity that may occupy a city. It contains a name and
-// the name of the city it currently occupies.
+// Alien implements an entity that may occupy a city. It contains a name, the
+// name of the city it currently occupies, whether or not it is trapped in
+// that city, i.e. the city has no out links (edges) to leave it by, the set of
+// names of all the cities it has ever occupied and its attributes.
 type Alien struct {
 	name     string
 	cityName string
+	trapped  bool
+	visited  map[string]bool
+	attrs    Attributes
+}
+
+// newAlien returns a reference to a new Alien with the given name occupying
+// the city with the given name and the default attributes.
+func newAlien(alienName, cityName string) *Alien {
+	return &Alien{
+		name:     alienName,
+		cityName: cityName,
+		visited:  map[string]bool{cityName: true},
+		attrs:    DefaultAttributes(),
+	}
 }
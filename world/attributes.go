@@ -0,0 +1,231 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+// MaxAttribute is the maximum strength, health and speed of an alien, the
+// maximum of any range and the maximum total weight of all species, so that
+// values drawn from them never overflow.
+const MaxAttribute = math.MaxInt32
+
+type (
+	// Attributes reflects the typed attributes of an alien: its species, the
+	// strength it fights with, the health it can lose before being killed and
+	// its speed, which weighs how often it is chosen to move. Aliens of the
+	// same species are allies. Strength, health and speed are at least one.
+	Attributes struct {
+		Species  string `json:"species,omitempty"`
+		Strength uint   `json:"strength"`
+		Health   uint   `json:"health"`
+		Speed    uint   `json:"speed"`
+	}
+
+	// Range reflects an inclusive range of attribute values. A zero range
+	// reflects the default value of one.
+	Range struct {
+		Min uint `json:"min"`
+		Max uint `json:"max"`
+	}
+
+	// Species reflects a species of aliens that may be seeded. A species is
+	// chosen with a probability proportional to its weight and the attributes
+	// of a seeded alien are drawn uniformly from the ranges of its species.
+	Species struct {
+		Name     string `json:"name"`
+		Weight   uint   `json:"weight"`
+		Strength Range  `json:"strength"`
+		Health   Range  `json:"health"`
+		Speed    Range  `json:"speed"`
+	}
+
+	// Roster reflects the attributes of aliens: those of individual aliens by
+	// name and the distribution of species that the attributes of all other
+	// seeded aliens are drawn from. Omitted strength, health and speed values
+	// default to one.
+	Roster struct {
+		Aliens  map[string]Attributes `json:"aliens,omitempty"`
+		Species []Species             `json:"species,omitempty"`
+	}
+)
+
+// DefaultAttributes returns the attributes of an alien without a species and
+// with a strength, health and speed of one.
+func DefaultAttributes() Attributes {
+	return Attributes{Strength: 1, Health: 1, Speed: 1}
+}
+
+// withDefaults returns the attributes with any omitted strength, health and
+// speed values set to one.
+func (a Attributes) withDefaults() Attributes {
+	if a.Strength == 0 {
+		a.Strength = 1
+	}
+
+	if a.Health == 0 {
+		a.Health = 1
+	}
+
+	if a.Speed == 0 {
+		a.Speed = 1
+	}
+
+	return a
+}
+
+// Validate returns an error if any strength, health or speed value exceeds
+// MaxAttribute.
+func (a Attributes) Validate() error {
+	switch {
+	case a.Strength > MaxAttribute:
+		return fmt.Errorf("strength %d exceeds the maximum of %d", a.Strength, MaxAttribute)
+
+	case a.Health > MaxAttribute:
+		return fmt.Errorf("health %d exceeds the maximum of %d", a.Health, MaxAttribute)
+
+	case a.Speed > MaxAttribute:
+		return fmt.Errorf("speed %d exceeds the maximum of %d", a.Speed, MaxAttribute)
+	}
+
+	return nil
+}
+
+// Validate returns an error if the range is invalid.
+func (r Range) Validate() error {
+	if r.Min > r.Max {
+		return fmt.Errorf("minimum %d is greater than maximum %d", r.Min, r.Max)
+	}
+
+	if r.Max > MaxAttribute {
+		return fmt.Errorf("maximum %d exceeds the maximum of %d", r.Max, MaxAttribute)
+	}
+
+	if r.Max != 0 && r.Min == 0 {
+		return errors.New("minimum must be greater than zero")
+	}
+
+	return nil
+}
+
+// draw returns a value drawn uniformly from the range. A zero range always
+// results in one without drawing from the random number generator.
+func (r Range) draw(rand *rng.Rand) uint {
+	if r.Max == 0 {
+		return 1
+	}
+
+	if r.Min == r.Max {
+		return r.Min
+	}
+
+	return r.Min + uint(rand.Uint64n(uint64(r.Max-r.Min)+1))
+}
+
+// ReadRoster reads a JSON encoded roster from the given reader. An error is
+// returned if the roster cannot be decoded or is invalid.
+func ReadRoster(r io.Reader) (Roster, error) {
+	var roster Roster
+
+	if err := json.NewDecoder(r).Decode(&roster); err != nil {
+		return Roster{}, err
+	}
+
+	return roster, roster.Validate()
+}
+
+// Validate returns an error if the roster is invalid, i.e. any alien has
+// invalid attributes, any species is unnamed, defined twice, has no weight or
+// an invalid range or the total weight of all species exceeds MaxAttribute.
+func (r Roster) Validate() error {
+	for alienName, attrs := range r.Aliens {
+		if err := attrs.Validate(); err != nil {
+			return fmt.Errorf("invalid attributes of alien %s: %v", alienName, err)
+		}
+	}
+
+	names := make(map[string]bool, len(r.Species))
+
+	var weight uint
+	for _, s := range r.Species {
+		switch {
+		case len(s.Name) == 0:
+			return errors.New("invalid species: missing name")
+
+		case names[s.Name]:
+			return fmt.Errorf("invalid species %s: duplicate definition", s.Name)
+
+		case s.Weight == 0:
+			return fmt.Errorf("invalid species %s: weight must be greater than zero", s.Name)
+		}
+
+		names[s.Name] = true
+
+		if weight += s.Weight; s.Weight > MaxAttribute || weight > MaxAttribute {
+			return fmt.Errorf("invalid species %s: total weight exceeds the maximum of %d", s.Name, MaxAttribute)
+		}
+
+		if err := s.Strength.Validate(); err != nil {
+			return fmt.Errorf("invalid strength of species %s: %v", s.Name, err)
+		}
+
+		if err := s.Health.Validate(); err != nil {
+			return fmt.Errorf("invalid health of species %s: %v", s.Name, err)
+		}
+
+		if err := s.Speed.Validate(); err != nil {
+			return fmt.Errorf("invalid speed of species %s: %v", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// clone returns a deep copy of the roster.
+func (r Roster) clone() Roster {
+	c := Roster{
+		Aliens:  make(map[string]Attributes, len(r.Aliens)),
+		Species: append([]Species(nil), r.Species...),
+	}
+
+	for name, attrs := range r.Aliens {
+		c.Aliens[name] = attrs
+	}
+
+	return c
+}
+
+// drawAttributes returns the attributes of a seeded alien drawn from the
+// roster's distribution of species. The default attributes are returned
+// without drawing from the random number generator if the roster has no
+// species.
+func (r Roster) drawAttributes(rand *rng.Rand) Attributes {
+	if len(r.Species) == 0 {
+		return DefaultAttributes()
+	}
+
+	var total uint64
+	for _, s := range r.Species {
+		total += uint64(s.Weight)
+	}
+
+	i, w := 0, rand.Uint64n(total)
+	for w >= uint64(r.Species[i].Weight) {
+		w -= uint64(r.Species[i].Weight)
+		i++
+	}
+
+	s := r.Species[i]
+
+	return Attributes{
+		Species:  s.Name,
+		Strength: s.Strength.draw(rand),
+		Health:   s.Health.draw(rand),
+		Speed:    s.Speed.draw(rand),
+	}
+}
@@ -0,0 +1,260 @@
+package world
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestReadRoster(t *testing.T) {
+	roster, err := ReadRoster(strings.NewReader(`{
+		"aliens": {"alien1": {"species": "queen", "strength": 5}},
+		"species": [
+			{"name": "grey", "weight": 3, "strength": {"min": 1, "max": 2}},
+			{"name": "zerg", "weight": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a := roster.Aliens["alien1"]; a != (Attributes{Species: "queen", Strength: 5}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", Attributes{Species: "queen", Strength: 5}, a)
+	}
+
+	if len(roster.Species) != 2 || roster.Species[0].Strength != (Range{Min: 1, Max: 2}) {
+		t.Errorf("incorrect result: %v", roster.Species)
+	}
+
+	invalid := []string{
+		`{"species": [{"weight": 1}]}`,
+		`{"species": [{"name": "grey", "weight": 1}, {"name": "grey", "weight": 2}]}`,
+		`{"species": [{"name": "grey"}]}`,
+		`{"species": [{"name": "grey", "weight": 1, "health": {"min": 3, "max": 2}}]}`,
+		`{"species": [{"name": "grey", "weight": 1, "speed": {"min": 0, "max": 2}}]}`,
+		`{"species": [{"name": "grey", "weight": 1, "strength": {"min": 1, "max": 18446744073709551615}}]}`,
+		`{"species": [{"name": "grey", "weight": 2147483647}, {"name": "zerg", "weight": 1}]}`,
+		`{"aliens": {"alien1": {"speed": 2147483648}}}`,
+		`{"species": `,
+	}
+
+	for _, data := range invalid {
+		if _, err := ReadRoster(strings.NewReader(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestSetRoster(t *testing.T) {
+	m := buildMapFixtureSimple()
+
+	roster := Roster{Aliens: map[string]Attributes{"alien1": {Species: "queen", Health: 4}, "alien9": {Strength: 2}}}
+	if err := m.SetRoster(roster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Omitted values default to one.
+	e := Attributes{Species: "queen", Strength: 1, Health: 4, Speed: 1}
+	if a, _ := m.AlienAttributes("alien1"); a != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, a)
+	}
+
+	if a, _ := m.AlienAttributes("alien2"); a != DefaultAttributes() {
+		t.Errorf("incorrect result: expected: %v, got: %v", DefaultAttributes(), a)
+	}
+
+	// Aliens added later on take on their listed attributes.
+	m.AddCity("baz")
+	if err := m.AddAlien("alien9", "baz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e = Attributes{Strength: 2, Health: 1, Speed: 1}
+	if a, _ := m.AlienAttributes("alien9"); a != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, a)
+	}
+
+	if err := m.SetRoster(Roster{Species: []Species{{Name: "grey"}}}); err == nil {
+		t.Error("expected error for invalid roster")
+	}
+
+	if err := m.SetAlienAttributes("alien42", e); err == nil {
+		t.Error("expected error for unknown alien")
+	}
+
+	if err := m.SetAlienAttributes("alien1", Attributes{Strength: MaxAttribute + 1}); err == nil {
+		t.Error("expected error for invalid attributes")
+	}
+}
+
+func TestSeedAliensAttributes(t *testing.T) {
+	build := func() *Map {
+		m := NewMap(rng.NewRand(3))
+		for _, cityName := range []string{"a", "b", "c", "d", "e", "f"} {
+			m.AddLink(cityName, "north", "hub")
+		}
+
+		return m
+	}
+
+	// Seeding without any species draws nothing beyond the placement.
+	m, other := build(), build()
+	if err := other.SetRoster(Roster{Aliens: map[string]Attributes{"alien2": {Species: "queen"}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.SeedAliens(6)
+	other.SeedAliens(6)
+
+	for _, alienName := range m.AlienNames() {
+		city, _ := m.AlienCity(alienName)
+		if oc, _ := other.AlienCity(alienName); oc != city {
+			t.Errorf("incorrect city of %s: expected: %s, got: %s", alienName, city, oc)
+		}
+	}
+
+	if a, _ := other.AlienAttributes("alien2"); a.Species != "queen" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "queen", a.Species)
+	}
+
+	m = build()
+	roster := Roster{
+		Aliens: map[string]Attributes{"alien1": {Species: "queen", Strength: 9}},
+		Species: []Species{
+			{Name: "grey", Weight: 1, Strength: Range{Min: 2, Max: 3}, Health: Range{Min: 4, Max: 4}},
+			{Name: "zerg", Weight: 1, Speed: Range{Min: 1, Max: 2}},
+		},
+	}
+
+	if err := m.SetRoster(roster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.SeedAliens(12)
+
+	species := make(map[string]int)
+
+	for _, alienName := range m.AlienNames() {
+		a, _ := m.AlienAttributes(alienName)
+		species[a.Species]++
+
+		switch {
+		case alienName == "alien1":
+			if a != (Attributes{Species: "queen", Strength: 9, Health: 1, Speed: 1}) {
+				t.Errorf("incorrect attributes of %s: %v", alienName, a)
+			}
+
+		case a.Species == "grey":
+			if a.Strength < 2 || a.Strength > 3 || a.Health != 4 || a.Speed != 1 {
+				t.Errorf("incorrect attributes of %s: %v", alienName, a)
+			}
+
+		case a.Species == "zerg":
+			if a.Strength != 1 || a.Health != 1 || a.Speed < 1 || a.Speed > 2 {
+				t.Errorf("incorrect attributes of %s: %v", alienName, a)
+			}
+
+		default:
+			t.Errorf("unexpected species of %s: %v", alienName, a)
+		}
+	}
+
+	if species["grey"] == 0 || species["zerg"] == 0 {
+		t.Errorf("expected both species to be seeded: %v", species)
+	}
+}
+
+func TestAttributesJSON(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.SetAlienAttributes("alien3", Attributes{Species: "grey", Strength: 2, Health: 3, Speed: 4})
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Default attributes are omitted.
+	if n := strings.Count(string(data), `"attributes"`); n != 1 {
+		t.Errorf("incorrect number of encoded attributes: expected: %d, got: %d", 1, n)
+	}
+
+	decoded := NewMap(rng.NewRand(1))
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !decoded.Equal(m) {
+		t.Errorf("incorrect result: expected: %v, got: %v", m, decoded)
+	}
+
+	m.SetAlienAttributes("alien3", DefaultAttributes())
+
+	if decoded.Equal(m) {
+		t.Error("expected maps with different attributes to differ")
+	}
+}
+
+func TestMoveAlienSpeed(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("baz", "north", "qux")
+	m.AddLink("qux", "south", "baz")
+	m.AddAlien("alien1", "foo")
+	m.AddAlien("alien2", "baz")
+	m.SetAlienAttributes("alien1", Attributes{Speed: 9})
+
+	moves := make(map[string]int)
+
+	for i := 0; i < 1000; i++ {
+		move, err := m.MoveAlien()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		moves[move.Alien]++
+	}
+
+	// alien1 is chosen with probability 0.9.
+	if moves["alien1"] < 850 || moves["alien1"] > 950 {
+		t.Errorf("incorrect number of moves of alien1: expected about %d, got: %d", 900, moves["alien1"])
+	}
+}
+
+func TestMaxAttributeTotals(t *testing.T) {
+	m := buildMapFixtureEmpty()
+	m.config = Config{Capacity: 3, FightThreshold: 3, MaxEdges: 4}
+	m.SetFightResolver(Strength{})
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	// The totals of maximum attributes exceed the range of a 32-bit int.
+	max := Attributes{Strength: MaxAttribute, Health: MaxAttribute, Speed: MaxAttribute}
+
+	for _, alienName := range []string{"alien1", "alien2"} {
+		m.AddAlien(alienName, "foo")
+		m.SetAlienAttributes(alienName, max)
+	}
+
+	if _, err := m.MoveAlien(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.AddAlien("alien3", "bar")
+	m.SetAlienAttributes("alien3", max)
+
+	for _, alienName := range []string{"alien1", "alien2"} {
+		if cityName, _ := m.AlienCity(alienName); cityName != "bar" {
+			m.ApplyMove(alienName, "north")
+		}
+	}
+
+	// The winner is killed by the combined strength of the others.
+	if f := m.ResolveFights(); len(f) != 1 || !f[0].Destroyed {
+		t.Errorf("incorrect result: expected city bar to be destroyed, got: %v", f)
+	}
+}
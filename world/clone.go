@@ -0,0 +1,231 @@
+package world
+
+import (
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+// Clone returns a reference to a fully independent deep copy of the map,
+// including its cities, links (edges), aliens, destroyed cities, pending
+// collisions, movement strategies, fight resolver, roster and the state of its
+// random number generator. Mutating the clone never affects the original and
+// vice versa.
+func (m *Map) Clone() *Map {
+	var r *rng.Rand
+	if m.rng != nil {
+		r = m.rng.Clone()
+	}
+
+	return m.CloneWithRand(r)
+}
+
+// CloneWithRand returns a reference to a fully independent deep copy of the
+// map just like Clone, except that the copy draws all of its pseudo
+// randomness from the given random number generator instead.
+func (m *Map) CloneWithRand(r *rng.Rand) *Map {
+	c := &Map{
+		cities:          make(map[string]*City, len(m.cities)),
+		cityOrder:       append([]string(nil), m.cityOrder...),
+		aliens:          make(map[string]*Alien, len(m.aliens)),
+		mobile:          append([]string(nil), m.mobile...),
+		destroyed:       make([]Destruction, 0, len(m.destroyed)),
+		rng:             r,
+		config:          m.config,
+		strategy:        m.strategy,
+		alienStrategies: make(map[string]MovementStrategy, len(m.alienStrategies)),
+		resolver:        m.resolver,
+		roster:          m.roster.clone(),
+	}
+
+	for name, s := range m.alienStrategies {
+		c.alienStrategies[name] = s
+	}
+
+	for name, alien := range m.aliens {
+		clone := *alien
+		clone.visited = make(map[string]bool, len(alien.visited))
+
+		for cityName := range alien.visited {
+			clone.visited[cityName] = true
+		}
+
+		c.aliens[name] = &clone
+	}
+
+	for name, city := range m.cities {
+		cc := &City{
+			name:           city.name,
+			inLinks:        make(map[string]uint, len(city.inLinks)),
+			outLinks:       make(map[string]string, len(city.outLinks)),
+			linkOrder:      append([]string(nil), city.linkOrder...),
+			alienOccupancy: make(map[string]*Alien, len(city.alienOccupancy)),
+			damage:         city.damage,
+			defined:        city.defined,
+		}
+
+		for k, v := range city.inLinks {
+			cc.inLinks[k] = v
+		}
+
+		for k, v := range city.outLinks {
+			cc.outLinks[k] = v
+		}
+
+		for alienName := range city.alienOccupancy {
+			cc.alienOccupancy[alienName] = c.aliens[alienName]
+		}
+
+		c.cities[name] = cc
+	}
+
+	for _, col := range m.collisions {
+		c.collisions = append(c.collisions, Collision{
+			Cities: col.Cities,
+			Aliens: append([]string(nil), col.Aliens...),
+		})
+	}
+
+	for _, d := range m.destroyed {
+		c.destroyed = append(c.destroyed, Destruction{
+			City:   d.City,
+			Aliens: append([]string(nil), d.Aliens...),
+			Roads:  append([]Road(nil), d.Roads...),
+		})
+	}
+
+	return c
+}
+
+// Equal returns a boolean on whether or not two maps are equal, i.e. they have
+// the same configuration, cities in the same order with the same links
+// (edges) and damage, aliens with the same attributes in the same cities that
+// have visited the same cities, destroyed cities and pending collisions.
+// Neither the state of their random number generators nor their movement
+// strategies, fight resolvers or rosters are compared.
+func (m *Map) Equal(other *Map) bool {
+	if m == other {
+		return true
+	}
+
+	if m == nil || other == nil || m.config != other.config {
+		return false
+	}
+
+	// The order of cities may still list destroyed cities, so only surviving
+	// cities are compared.
+	if !equalStrings(m.CityNames(), other.CityNames()) || len(m.cities) != len(other.cities) {
+		return false
+	}
+
+	for name, city := range m.cities {
+		oc, ok := other.cities[name]
+		if !ok || !city.equal(oc) {
+			return false
+		}
+	}
+
+	if len(m.aliens) != len(other.aliens) {
+		return false
+	}
+
+	for name, alien := range m.aliens {
+		oa, ok := other.aliens[name]
+		if !ok || !alien.equal(oa) {
+			return false
+		}
+	}
+
+	if len(m.destroyed) != len(other.destroyed) {
+		return false
+	}
+
+	for i, d := range m.destroyed {
+		od := other.destroyed[i]
+
+		if d.City != od.City || !equalStrings(d.Aliens, od.Aliens) || len(d.Roads) != len(od.Roads) {
+			return false
+		}
+
+		for j, road := range d.Roads {
+			if road != od.Roads[j] {
+				return false
+			}
+		}
+	}
+
+	if len(m.collisions) != len(other.collisions) {
+		return false
+	}
+
+	for i, c := range m.collisions {
+		oc := other.collisions[i]
+
+		if c.Cities != oc.Cities || !equalStrings(c.Aliens, oc.Aliens) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equal returns a boolean on whether or not two cities have the same name,
+// links (edges), damage and occupying aliens and are both defined or not.
+func (c *City) equal(other *City) bool {
+	if c.name != other.name || c.damage != other.damage || c.defined != other.defined || !equalStrings(c.LinkDirections(), other.LinkDirections()) {
+		return false
+	}
+
+	if len(c.inLinks) != len(other.inLinks) || len(c.outLinks) != len(other.outLinks) {
+		return false
+	}
+
+	for k, v := range c.inLinks {
+		if ov, ok := other.inLinks[k]; !ok || ov != v {
+			return false
+		}
+	}
+
+	for k, v := range c.outLinks {
+		if ov, ok := other.outLinks[k]; !ok || ov != v {
+			return false
+		}
+	}
+
+	return equalStrings(c.AlienNames(), other.AlienNames())
+}
+
+// equal returns a boolean on whether or not two aliens have the same name,
+// occupy the same city, are both trapped or not, have visited the same cities
+// and have the same attributes.
+func (a *Alien) equal(other *Alien) bool {
+	if a.name != other.name || a.cityName != other.cityName || a.trapped != other.trapped || a.attrs != other.attrs {
+		return false
+	}
+
+	if len(a.visited) != len(other.visited) {
+		return false
+	}
+
+	for cityName := range a.visited {
+		if !other.visited[cityName] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalStrings returns a boolean on whether or not two lists of strings
+// contain the same strings in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
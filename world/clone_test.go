@@ -0,0 +1,133 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestClone(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.AddLink("foo", "south", "qu-ux")
+
+	c := m.Clone()
+	if !c.Equal(m) || !m.Equal(c) {
+		t.Fatalf("expected clone to equal the original")
+	}
+
+	// Keep a second clone to verify the original is never mutated.
+	snapshot := m.Clone()
+
+	c.AddLink("qu-ux", "north", "baz")
+	c.ExecuteFights()
+
+	if err := c.AddAlien("alien5", "qu-ux"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := c.ApplyMove("alien5", "north"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.Equal(m) {
+		t.Errorf("expected mutated clone to differ from the original")
+	}
+
+	if !m.Equal(snapshot) {
+		t.Errorf("expected original to be unaffected by mutations of the clone")
+	}
+
+	if m.NumAliens() != 4 || len(m.DestroyedCities()) != 0 {
+		t.Errorf("incorrect result: expected: %v, got: %v", "4 aliens and no destroyed cities", m)
+	}
+
+	if city, _ := m.AlienCity("alien1"); city != "foo" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "foo", city)
+	}
+}
+
+func TestCloneDestroyed(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.AddLink("foo", "west", "baz")
+	m.AddLink("baz", "east", "foo")
+	m.SetFightResolver(RoadsOnly{})
+	m.ResolveFights()
+	m.SetFightResolver(DestroyAll{})
+
+	if err := m.AddAlien("alien5", "baz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien6", "baz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.ExecuteFights()
+
+	if len(m.DestroyedCities()) != 1 {
+		t.Fatalf("incorrect destroyed cities: %v", m.DestroyedCities())
+	}
+
+	if c := m.Clone(); !c.Equal(m) || !m.Equal(c) {
+		t.Errorf("expected clone to equal the original after a city was destroyed")
+	}
+}
+
+func TestCloneRand(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("bar", "east", "baz")
+	m.AddLink("baz", "west", "bar")
+	m.AddCity("qux")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := m.Clone()
+
+	// The clone continues the same pseudo random sequence independently.
+	for i := 0; i < 20; i++ {
+		m1, err1 := m.MoveAlien()
+		m2, err2 := c.MoveAlien()
+
+		if m1 != m2 || err1 != err2 {
+			t.Fatalf("incorrect result: expected: %v, got: %v", m1, m2)
+		}
+	}
+
+	if !c.Equal(m) {
+		t.Errorf("expected clone to equal the original after the same moves")
+	}
+
+	if r := m.CloneWithRand(rng.NewRand(7)); r.rng == m.rng || !r.Equal(m) {
+		t.Errorf("expected clone with its own random number generator to equal the original")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	m1 := buildMapFixtureSimple()
+	m2 := buildMapFixtureSimple()
+
+	if !m1.Equal(m2) {
+		t.Fatalf("expected identical maps to be equal")
+	}
+
+	m2.AddLink("bar", "east", "baz")
+	if m1.Equal(m2) {
+		t.Errorf("expected maps with different links to differ")
+	}
+
+	m3 := buildMapFixtureSimple()
+	m3.config.Capacity = 3
+
+	if m1.Equal(m3) {
+		t.Errorf("expected maps with different configurations to differ")
+	}
+
+	if m1.Equal(nil) {
+		t.Errorf("expected map to differ from nil")
+	}
+}
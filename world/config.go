@@ -0,0 +1,51 @@
+package world
+
+import (
+	"fmt"
+)
+
+// Config reflects the rules a world map is bound by.
+type Config struct {
+	// Capacity is the maximum number of aliens that may occupy any given
+	// city.
+	Capacity uint `json:"capacity"`
+	// FightThreshold is the number of aliens occupying a city at which they
+	// fight and destroy the city.
+	FightThreshold uint `json:"fight_threshold"`
+	// MaxEdges is the maximum number of links (edges) from a city.
+	MaxEdges uint `json:"max_edges"`
+}
+
+// DefaultConfig returns the default configuration of a world map, i.e. cities
+// hold at most MaxOccupancy aliens that fight once a city is full and cities
+// have at most MaxEdges links.
+func DefaultConfig() Config {
+	return Config{
+		Capacity:       MaxOccupancy,
+		FightThreshold: MaxOccupancy,
+		MaxEdges:       MaxEdges,
+	}
+}
+
+// Validate returns an error if the configuration is nonsensical, e.g. aliens
+// could never fight or cities could never be linked.
+func (c Config) Validate() error {
+	switch {
+	case c.Capacity == 0:
+		return fmt.Errorf("invalid capacity %d: must be greater than zero", c.Capacity)
+
+	case c.FightThreshold < 2:
+		return fmt.Errorf("invalid fight threshold %d: a fight requires at least two aliens", c.FightThreshold)
+
+	case c.FightThreshold > c.Capacity:
+		return fmt.Errorf(
+			"invalid fight threshold %d: cannot exceed the capacity of %d as aliens would never fight",
+			c.FightThreshold, c.Capacity,
+		)
+
+	case c.MaxEdges == 0 || c.MaxEdges > uint(len(Directions)):
+		return fmt.Errorf("invalid edge limit %d: must be between 1 and %d", c.MaxEdges, len(Directions))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		cfg   Config
+		valid bool
+	}{
+		{DefaultConfig(), true},
+		{Config{Capacity: 3, FightThreshold: 2, MaxEdges: 4}, true},
+		{Config{Capacity: 3, FightThreshold: 3, MaxEdges: 1}, true},
+		{Config{Capacity: 0, FightThreshold: 2, MaxEdges: 4}, false},
+		{Config{Capacity: 2, FightThreshold: 1, MaxEdges: 4}, false},
+		{Config{Capacity: 2, FightThreshold: 3, MaxEdges: 4}, false},
+		{Config{Capacity: 2, FightThreshold: 2, MaxEdges: 0}, false},
+		{Config{Capacity: 2, FightThreshold: 2, MaxEdges: 5}, false},
+	}
+
+	for _, tc := range testCases {
+		err := tc.cfg.Validate()
+
+		if tc.valid && err != nil {
+			t.Errorf("unexpected error for %+v: %v", tc.cfg, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected error for %+v", tc.cfg)
+		}
+	}
+
+	if _, err := NewMapWithConfig(rng.NewRand(1), Config{}); err == nil {
+		t.Error("expected error for zero configuration")
+	}
+}
+
+func TestMapConfig(t *testing.T) {
+	m, err := NewMapWithConfig(rng.NewRand(1), Config{Capacity: 3, FightThreshold: 3, MaxEdges: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddLink("foo", "north", "bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddLink("foo", "south", "baz"); err == nil {
+		t.Error("expected error when exceeding the edge limit")
+	}
+
+	// Replacing an existing road does not count towards the edge limit.
+	if err := m.AddLink("foo", "north", "baz"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	m.SeedAliens(3)
+
+	if city, _ := m.AlienCity("alien3"); city != "foo" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "foo", city)
+	}
+
+	destroyed := m.ExecuteFights()
+	if len(destroyed) != 1 || len(destroyed[0].Aliens) != 3 {
+		t.Errorf("incorrect result: expected: %v, got: %v", "foo destroyed by 3 aliens", destroyed)
+	}
+}
@@ -0,0 +1,42 @@
+package world
+
+// The set of directions a link (edge) between two cities may follow.
+const (
+	North = "north"
+	South = "south"
+	East  = "east"
+	West  = "west"
+)
+
+// Directions contains all valid link directions in their canonical order.
+var Directions = []string{North, South, East, West}
+
+// IsDirection returns a boolean on whether or not the given (lower case)
+// direction is a valid link direction.
+func IsDirection(dir string) bool {
+	for _, d := range Directions {
+		if d == dir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OppositeDirection returns the direction opposite to the given (lower case)
+// direction, e.g. south for north. An empty string is returned if the
+// direction is invalid.
+func OppositeDirection(dir string) string {
+	switch dir {
+	case North:
+		return South
+	case South:
+		return North
+	case East:
+		return West
+	case West:
+		return East
+	default:
+		return ""
+	}
+}
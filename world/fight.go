@@ -0,0 +1,248 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+type (
+	// FightResolver reflects how fights are resolved. Given a city occupied by
+	// at least the fight threshold of aliens and the names of those aliens
+	// (sorted by name), a resolver decides the outcome of the fight. Aliens
+	// that met on a road during a round fight on the road and are resolved
+	// alike, given a placeholder city that holds the fighting aliens and has
+	// no name, links or damage. Its Road method returns the road. Both cities
+	// and the road survive a fight on a road, so a decision to destroy the
+	// city only kills all the fighting aliens while damage and removing roads
+	// are ignored. All randomness must be drawn from the given random number
+	// generator. Resolvers must be stateless as they may be shared between
+	// maps.
+	FightResolver interface {
+		ResolveFight(city *City, alienNames []string, r *rng.Rand) FightOutcome
+		// String returns the specification of the resolver as accepted by
+		// ParseFightResolver.
+		String() string
+	}
+
+	// FightOutcome reflects the outcome of a fight as decided by a
+	// FightResolver.
+	FightOutcome struct {
+		// Killed reflects the names of the aliens killed in the fight. Names
+		// of aliens that did not take part in the fight are ignored.
+		Killed []string
+		// DestroyCity is true if the city is destroyed, in which case every
+		// alien occupying it is killed and all its roads are removed.
+		DestroyCity bool
+		// Damage is the damage dealt to the city if it survives.
+		Damage uint
+		// DestroyRoads is true if all the roads leading into or out of the
+		// city are removed while the city survives.
+		DestroyRoads bool
+		// Injuries reflects the health lost by aliens that are not killed, by
+		// name. An alien that loses all of its health is killed.
+		Injuries map[string]uint
+	}
+
+	// Fight records a fight that happened in a city: the aliens that fought,
+	// the aliens that were killed, the health lost by the surviving aliens,
+	// whether or not the city was destroyed, the damage dealt to it if it
+	// survived and the roads that were removed as a result. A fight on a road
+	// has no city, but the names of the two cities linked by the road in name
+	// order.
+	Fight struct {
+		City      string          `json:"city,omitempty"`
+		Road      []string        `json:"road,omitempty"`
+		Aliens    []string        `json:"aliens"`
+		Killed    []string        `json:"killed"`
+		Injuries  map[string]uint `json:"injuries,omitempty"`
+		Destroyed bool            `json:"destroyed"`
+		Damage    uint            `json:"damage,omitempty"`
+		Roads     []Road          `json:"roads,omitempty"`
+	}
+
+	// DestroyAll implements a FightResolver where all the fighting aliens are
+	// killed and the city is destroyed along with them.
+	DestroyAll struct{}
+
+	// Winner implements a FightResolver where, with probability P, a uniformly
+	// random alien wins the fight and survives while all the other aliens are
+	// killed and the city survives. Otherwise, the fight is resolved just like
+	// DestroyAll.
+	Winner struct {
+		P float64
+	}
+
+	// CityDamage implements a FightResolver where all the fighting aliens are
+	// killed and every fight deals one damage to the city. The city is
+	// destroyed once it has taken Limit damage.
+	CityDamage struct {
+		Limit uint
+	}
+
+	// RoadsOnly implements a FightResolver where all the fighting aliens are
+	// killed and all the roads leading into or out of the city are removed
+	// while the city itself survives.
+	RoadsOnly struct{}
+
+	// Strength implements a FightResolver based on the attributes of the
+	// fighting aliens. A winner is chosen at random weighted by the aliens'
+	// strength and all the other aliens are killed. The winner is injured by
+	// the total strength of the killed aliens of other species, as allies do
+	// not injure each other. If the winner loses all of its health, the
+	// fight is resolved just like DestroyAll. Otherwise, the city survives.
+	Strength struct{}
+)
+
+// ParseFightResolver returns the fight resolver with the given specification:
+// destroy, winner:<p> where p is the probability of a winner, damage:<n> where
+// n is the damage a city can take, roads or strength. An error is returned if
+// the specification is invalid.
+func ParseFightResolver(spec string) (FightResolver, error) {
+	name, arg := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		name, arg = spec[:i], spec[i+1:]
+	}
+
+	switch strings.ToLower(name) {
+	case "destroy":
+		return DestroyAll{}, checkNoResolverArg(spec, arg)
+
+	case "winner":
+		p, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(p) || p < 0 || p > 1 {
+			return nil, fmt.Errorf("invalid fight resolver %q: probability must be in [0, 1]", spec)
+		}
+
+		return Winner{P: p}, nil
+
+	case "damage":
+		limit, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil || limit == 0 {
+			return nil, fmt.Errorf("invalid fight resolver %q: damage limit must be greater than zero", spec)
+		}
+
+		return CityDamage{Limit: uint(limit)}, nil
+
+	case "roads":
+		return RoadsOnly{}, checkNoResolverArg(spec, arg)
+
+	case "strength":
+		return Strength{}, checkNoResolverArg(spec, arg)
+	}
+
+	return nil, fmt.Errorf("unknown fight resolver %q: must be one of destroy, winner:<p>, damage:<n>, roads or strength", spec)
+}
+
+// checkNoResolverArg returns an error if a fight resolver that takes no
+// argument was given one.
+func checkNoResolverArg(spec, arg string) error {
+	if len(arg) != 0 {
+		return fmt.Errorf("invalid fight resolver %q: takes no argument", spec)
+	}
+
+	return nil
+}
+
+// ResolveFight implements the FightResolver interface.
+func (DestroyAll) ResolveFight(_ *City, alienNames []string, _ *rng.Rand) FightOutcome {
+	return FightOutcome{Killed: alienNames, DestroyCity: true}
+}
+
+// String implements the FightResolver interface.
+func (DestroyAll) String() string { return "destroy" }
+
+// ResolveFight implements the FightResolver interface.
+func (w Winner) ResolveFight(city *City, alienNames []string, r *rng.Rand) FightOutcome {
+	if r.Float64() >= w.P {
+		return DestroyAll{}.ResolveFight(city, alienNames, r)
+	}
+
+	winner := r.Intn(len(alienNames))
+	killed := make([]string, 0, len(alienNames)-1)
+
+	for i, alienName := range alienNames {
+		if i != winner {
+			killed = append(killed, alienName)
+		}
+	}
+
+	return FightOutcome{Killed: killed}
+}
+
+// String implements the FightResolver interface.
+func (w Winner) String() string {
+	return "winner:" + strconv.FormatFloat(w.P, 'g', -1, 64)
+}
+
+// ResolveFight implements the FightResolver interface.
+func (d CityDamage) ResolveFight(city *City, alienNames []string, _ *rng.Rand) FightOutcome {
+	if city.Damage()+1 >= d.Limit {
+		return FightOutcome{Killed: alienNames, DestroyCity: true}
+	}
+
+	return FightOutcome{Killed: alienNames, Damage: 1}
+}
+
+// String implements the FightResolver interface.
+func (d CityDamage) String() string {
+	return "damage:" + strconv.FormatUint(uint64(d.Limit), 10)
+}
+
+// ResolveFight implements the FightResolver interface.
+func (RoadsOnly) ResolveFight(_ *City, alienNames []string, _ *rng.Rand) FightOutcome {
+	return FightOutcome{Killed: alienNames, DestroyRoads: true}
+}
+
+// String implements the FightResolver interface.
+func (RoadsOnly) String() string { return "roads" }
+
+// ResolveFight implements the FightResolver interface.
+func (Strength) ResolveFight(city *City, alienNames []string, r *rng.Rand) FightOutcome {
+	attrs := make([]Attributes, len(alienNames))
+
+	var total uint64
+	for i, alienName := range alienNames {
+		attrs[i], _ = city.AlienAttributes(alienName)
+		total += uint64(attrs[i].Strength)
+	}
+
+	winner, w := 0, r.Uint64n(total)
+	for w >= uint64(attrs[winner].Strength) {
+		w -= uint64(attrs[winner].Strength)
+		winner++
+	}
+
+	var injury uint64
+	killed := make([]string, 0, len(alienNames)-1)
+
+	for i, alienName := range alienNames {
+		if i == winner {
+			continue
+		}
+
+		killed = append(killed, alienName)
+
+		species := attrs[i].Species
+		if len(species) == 0 || species != attrs[winner].Species {
+			injury += uint64(attrs[i].Strength)
+		}
+	}
+
+	if injury >= uint64(attrs[winner].Health) {
+		return DestroyAll{}.ResolveFight(city, alienNames, r)
+	}
+
+	outcome := FightOutcome{Killed: killed}
+	if injury != 0 {
+		outcome.Injuries = map[string]uint{alienNames[winner]: uint(injury)}
+	}
+
+	return outcome
+}
+
+// String implements the FightResolver interface.
+func (Strength) String() string { return "strength" }
@@ -0,0 +1,249 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestParseFightResolver(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected FightResolver
+	}{
+		{"destroy", DestroyAll{}},
+		{"Winner:0.5", Winner{P: 0.5}},
+		{"winner:0", Winner{P: 0}},
+		{"damage:3", CityDamage{Limit: 3}},
+		{"roads", RoadsOnly{}},
+		{"strength", Strength{}},
+	}
+
+	for _, tc := range testCases {
+		r, err := ParseFightResolver(tc.spec)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", tc.spec, err)
+			continue
+		}
+
+		if r != tc.expected {
+			t.Errorf("incorrect result: expected: %v, got: %v", tc.expected, r)
+		}
+
+		// The specification of a resolver parses back into the same resolver.
+		if p, _ := ParseFightResolver(r.String()); p != r {
+			t.Errorf("incorrect result: expected: %v, got: %v", r, p)
+		}
+	}
+
+	for _, spec := range []string{"", "nuke", "destroy:1", "winner", "winner:2", "winner:NaN", "damage:0", "damage:x", "roads:1", "strength:2"} {
+		if _, err := ParseFightResolver(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestResolveFightsWinner(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.SetFightResolver(Winner{P: 1})
+
+	fights := m.ResolveFights()
+	if len(fights) != 2 {
+		t.Fatalf("incorrect number of fights: expected: %d, got: %d", 2, len(fights))
+	}
+
+	for _, f := range fights {
+		if f.Destroyed || len(f.Killed) != 1 || len(f.Roads) != 0 {
+			t.Errorf("unexpected fight outcome: %v", f)
+		}
+
+		if occupancy := m.Occupancy(f.City); occupancy != 1 {
+			t.Errorf("incorrect occupancy of %s: expected: %d, got: %d", f.City, 1, occupancy)
+		}
+	}
+
+	if len(m.cities) != 2 || len(m.aliens) != 2 || len(m.DestroyedCities()) != 0 {
+		t.Errorf("unexpected map after fights: %v", m)
+	}
+
+	// A city hosts no further fight once a single alien remains.
+	if fights := m.ResolveFights(); len(fights) != 0 {
+		t.Errorf("unexpected fights: %v", fights)
+	}
+}
+
+func TestResolveFightsDamage(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.SetFightResolver(CityDamage{Limit: 2})
+
+	e := []Fight{
+		{City: "foo", Aliens: []string{"alien1", "alien2"}, Killed: []string{"alien1", "alien2"}, Damage: 1},
+		{City: "bar", Aliens: []string{"alien3", "alien4"}, Killed: []string{"alien3", "alien4"}, Damage: 1},
+	}
+
+	if r := m.ResolveFights(); !reflect.DeepEqual(r, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+
+	if damage, ok := m.CityDamage("foo"); !ok || damage != 1 {
+		t.Errorf("incorrect damage: expected: %d, got: %d", 1, damage)
+	}
+
+	// The second fight in the same city reaches the damage limit.
+	m.AddAlien("alien5", "foo")
+	m.AddAlien("alien6", "foo")
+
+	r := m.ResolveFights()
+	if len(r) != 1 || !r[0].Destroyed || r[0].City != "foo" {
+		t.Errorf("unexpected fights: %v", r)
+	}
+
+	if _, ok := m.CityDamage("foo"); ok {
+		t.Error("expected foo to be destroyed")
+	}
+
+	if len(m.DestroyedCities()) != 1 {
+		t.Errorf("incorrect destroyed cities: %v", m.DestroyedCities())
+	}
+}
+
+func TestResolveFightsRoadsOnly(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.SetFightResolver(RoadsOnly{})
+
+	e := []Fight{
+		{
+			City:   "foo",
+			Aliens: []string{"alien1", "alien2"},
+			Killed: []string{"alien1", "alien2"},
+			Roads: []Road{
+				{From: "bar", Direction: "south", To: "foo"},
+				{From: "foo", Direction: "north", To: "bar"},
+			},
+		},
+		{City: "bar", Aliens: []string{"alien3", "alien4"}, Killed: []string{"alien3", "alien4"}},
+	}
+
+	if r := m.ResolveFights(); !reflect.DeepEqual(r, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+
+	if len(m.cities) != 2 || len(m.aliens) != 0 || len(m.DestroyedCities()) != 0 {
+		t.Errorf("unexpected map after fights: %v", m)
+	}
+
+	for _, city := range m.Cities() {
+		if len(city.outLinks) != 0 || len(city.inLinks) != 0 {
+			t.Errorf("expected %s to have no roads: %v", city.name, city)
+		}
+	}
+}
+
+func TestResolveFightsThreeWay(t *testing.T) {
+	m := buildMapFixtureEmpty()
+	m.config = Config{Capacity: 3, FightThreshold: 3, MaxEdges: 4}
+
+	m.AddLink("foo", "north", "bar")
+
+	for _, alienName := range []string{"alien1", "alien2"} {
+		m.AddAlien(alienName, "foo")
+	}
+
+	if fights := m.ResolveFights(); len(fights) != 0 {
+		t.Errorf("unexpected fights below the fight threshold: %v", fights)
+	}
+
+	m.AddAlien("alien3", "foo")
+
+	e := []Fight{
+		{
+			City:      "foo",
+			Aliens:    []string{"alien1", "alien2", "alien3"},
+			Killed:    []string{"alien1", "alien2", "alien3"},
+			Destroyed: true,
+			Roads:     []Road{{From: "foo", Direction: "north", To: "bar"}},
+		},
+	}
+
+	if r := m.ResolveFights(); !reflect.DeepEqual(r, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+}
+
+func TestResolveFightsStrength(t *testing.T) {
+	testCases := []struct {
+		attrs     map[string]Attributes
+		destroyed bool
+	}{
+		// The winner is injured by the strength of the killed alien.
+		{map[string]Attributes{"alien1": {Strength: 1, Health: 5}, "alien2": {Strength: 2, Health: 5}}, false},
+		// Allies do not injure each other.
+		{map[string]Attributes{"alien1": {Species: "grey", Health: 1}, "alien2": {Species: "grey", Health: 1}}, false},
+		// A winner that loses all of its health dies along with the city.
+		{map[string]Attributes{"alien1": {Strength: 3, Health: 1}, "alien2": {Strength: 3, Health: 1}}, true},
+	}
+
+	for i, tc := range testCases {
+		m := buildMapFixtureSimple()
+		m.SetFightResolver(Strength{})
+
+		for alienName, attrs := range tc.attrs {
+			m.SetAlienAttributes(alienName, attrs)
+		}
+
+		f := m.ResolveFights()[0]
+		if f.City != "foo" || f.Destroyed != tc.destroyed {
+			t.Errorf("unexpected fight outcome of test case %d: %v", i, f)
+			continue
+		}
+
+		if tc.destroyed {
+			continue
+		}
+
+		if len(f.Killed) != 1 {
+			t.Errorf("unexpected fight outcome of test case %d: %v", i, f)
+			continue
+		}
+
+		winner := "alien1"
+		if f.Killed[0] == winner {
+			winner = "alien2"
+		}
+
+		loser := tc.attrs[f.Killed[0]]
+		injury := f.Injuries[winner]
+
+		if loser.Species == "" && injury != loser.Strength {
+			t.Errorf("incorrect injury of test case %d: expected: %d, got: %d", i, loser.Strength, injury)
+		} else if loser.Species != "" && injury != 0 {
+			t.Errorf("incorrect injury of test case %d: expected: %d, got: %d", i, 0, injury)
+		}
+
+		if a, _ := m.AlienAttributes(winner); a.Health != tc.attrs[winner].Health-injury {
+			t.Errorf("incorrect health of test case %d: expected: %d, got: %d", i, tc.attrs[winner].Health-injury, a.Health)
+		}
+	}
+
+	// The winner is chosen weighted by strength.
+	wins := make(map[string]int)
+
+	for seed := int64(0); seed < 500; seed++ {
+		m := buildMapFixtureSimple()
+		m.rng = rng.NewRand(seed)
+		m.SetFightResolver(Strength{})
+		m.SetAlienAttributes("alien1", Attributes{Strength: 1, Health: 10})
+		m.SetAlienAttributes("alien2", Attributes{Strength: 4, Health: 10})
+
+		m.ResolveFights()
+
+		for _, alienName := range m.cities["foo"].AlienNames() {
+			wins[alienName]++
+		}
+	}
+
+	if wins["alien2"] < 350 || wins["alien2"] > 450 {
+		t.Errorf("incorrect number of wins of alien2: expected about %d, got: %d", 400, wins["alien2"])
+	}
+}
@@ -0,0 +1,180 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+type (
+	// mapJSON is the JSON representation of a world map. Cities and their
+	// roads are listed in the order they were added while aliens are listed by
+	// name.
+	mapJSON struct {
+		Cities []cityJSON  `json:"cities"`
+		Aliens []alienJSON `json:"aliens,omitempty"`
+	}
+
+	// cityJSON is the JSON representation of a city and its roads (out links).
+	// Referenced is true if the city was never defined in its own right, but
+	// only referenced by the roads of other cities.
+	cityJSON struct {
+		Name       string     `json:"name"`
+		Roads      []roadJSON `json:"roads,omitempty"`
+		Damage     uint       `json:"damage,omitempty"`
+		Referenced bool       `json:"referenced,omitempty"`
+	}
+
+	// roadJSON is the JSON representation of a road (out link) to another
+	// city in a given direction.
+	roadJSON struct {
+		Direction string `json:"direction"`
+		City      string `json:"city"`
+	}
+
+	// alienJSON is the JSON representation of an alien, the city it occupies
+	// and its attributes, which are omitted if they are the default ones.
+	alienJSON struct {
+		Name       string      `json:"name"`
+		City       string      `json:"city"`
+		Attributes *Attributes `json:"attributes,omitempty"`
+	}
+)
+
+// MarshalJSON implements the json.Marshaler interface. The map is encoded as
+// its cities, their roads and damage and the aliens occupying them along with
+// their attributes.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	mj := mapJSON{
+		Cities: make([]cityJSON, 0, len(m.cities)),
+		Aliens: make([]alienJSON, 0, len(m.aliens)),
+	}
+
+	for _, city := range m.Cities() {
+		cj := cityJSON{Name: city.name, Damage: city.damage, Referenced: !city.defined}
+
+		for _, linkDir := range city.LinkDirections() {
+			cj.Roads = append(cj.Roads, roadJSON{Direction: linkDir, City: city.outLinks[linkDir]})
+		}
+
+		mj.Cities = append(mj.Cities, cj)
+	}
+
+	for _, alienName := range m.AlienNames() {
+		alien := m.aliens[alienName]
+		aj := alienJSON{Name: alienName, City: alien.cityName}
+
+		if alien.attrs != DefaultAttributes() {
+			attrs := alien.attrs
+			aj.Attributes = &attrs
+		}
+
+		mj.Aliens = append(mj.Aliens, aj)
+	}
+
+	return json.Marshal(mj)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Any existing
+// cities and aliens in the map are replaced while the map's random number
+// generator, configuration, movement strategies, fight resolver and roster are
+// kept. A map without a valid configuration, e.g. a zero value Map, uses the
+// default configuration instead and a map without a random number generator
+// draws from one seeded with zero. Aliens without attributes take on their
+// attributes listed in the roster, if any, or the default attributes
+// otherwise. Roads may lead to cities that are not listed, in which case those
+// cities are added without any roads. An error is returned if the map is
+// invalid, e.g. it contains unknown or duplicate directions, roads leading
+// back to the same city, more roads than allowed or aliens that cannot be
+// placed or have invalid attributes.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	var mj mapJSON
+
+	if err := json.Unmarshal(data, &mj); err != nil {
+		return err
+	}
+
+	wm := NewMap(m.rng)
+	if m.rng == nil {
+		wm.rng = rng.NewRand(0)
+	}
+
+	if err := m.config.Validate(); err == nil {
+		wm.config = m.config
+	}
+
+	if m.strategy != nil {
+		wm.strategy = m.strategy
+		wm.alienStrategies = m.alienStrategies
+	}
+
+	if m.resolver != nil {
+		wm.resolver = m.resolver
+	}
+
+	wm.roster = m.roster
+
+	for _, cj := range mj.Cities {
+		if len(cj.Name) == 0 {
+			return errors.New("invalid city: missing name")
+		}
+
+		if _, ok := wm.cities[cj.Name]; ok {
+			return fmt.Errorf("invalid city %s: duplicate definition", cj.Name)
+		}
+
+		wm.AddCity(cj.Name)
+		wm.cities[cj.Name].damage = cj.Damage
+		wm.cities[cj.Name].defined = !cj.Referenced
+	}
+
+	for _, cj := range mj.Cities {
+		linkDirs := make(map[string]bool, wm.config.MaxEdges)
+
+		for _, rj := range cj.Roads {
+			linkDir := strings.ToLower(rj.Direction)
+
+			switch {
+			case !IsDirection(linkDir):
+				return fmt.Errorf("invalid road of city %s: unknown direction %q", cj.Name, rj.Direction)
+
+			case linkDirs[linkDir]:
+				return fmt.Errorf("invalid road of city %s: duplicate direction %q", cj.Name, linkDir)
+
+			case len(rj.City) == 0:
+				return fmt.Errorf("invalid road of city %s: missing city name", cj.Name)
+
+			case rj.City == cj.Name:
+				return fmt.Errorf("invalid road of city %s: road leads back to the city itself", cj.Name)
+			}
+
+			linkDirs[linkDir] = true
+
+			if err := wm.AddLink(cj.Name, linkDir, rj.City); err != nil {
+				return fmt.Errorf("invalid road of city %s: %v", cj.Name, err)
+			}
+		}
+	}
+
+	for _, aj := range mj.Aliens {
+		if len(aj.Name) == 0 {
+			return errors.New("invalid alien: missing name")
+		}
+
+		if err := wm.AddAlien(aj.Name, aj.City); err != nil {
+			return fmt.Errorf("invalid alien %s: %v", aj.Name, err)
+		}
+
+		if aj.Attributes != nil {
+			if err := wm.SetAlienAttributes(aj.Name, *aj.Attributes); err != nil {
+				return fmt.Errorf("invalid alien %s: %v", aj.Name, err)
+			}
+		}
+	}
+
+	*m = *wm
+	return nil
+}
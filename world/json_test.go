@@ -0,0 +1,86 @@
+package world
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestMapJSONRoundTrip(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "west", "baz")
+	m.AddLink("bar", "south", "foo")
+	m.AddCity("qux")
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := `{"cities":[{"name":"foo","roads":[{"direction":"north","city":"bar"},{"direction":"west","city":"baz"}]},` +
+		`{"name":"bar","roads":[{"direction":"south","city":"foo"}]},{"name":"baz","referenced":true},{"name":"qux"}],` +
+		`"aliens":[{"name":"alien1","city":"foo"}]}`
+
+	if string(data) != e {
+		t.Errorf("incorrect result: expected: %s, got: %s", e, data)
+	}
+
+	r := NewMap(rng.NewRand(1))
+	if err := json.Unmarshal(data, r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.String() != m.String() {
+		t.Errorf("incorrect result: expected: %s, got: %s", m, r)
+	}
+
+	checkLinks(t, r)
+}
+
+func TestMapUnmarshalJSONZeroValue(t *testing.T) {
+	data := []byte(`{"cities":[{"name":"a","roads":[{"direction":"north","city":"b"}]}],` +
+		`"aliens":[{"name":"alien1","city":"a"},{"name":"alien2","city":"a"}]}`)
+
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.Config() != DefaultConfig() {
+		t.Errorf("incorrect result: expected: %v, got: %v", DefaultConfig(), m.Config())
+	}
+
+	if _, err := m.MoveAlien(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMapUnmarshalJSONInvalid(t *testing.T) {
+	testCases := []string{
+		`{"cities":[{"name":""}]}`,
+		`{"cities":[{"name":"foo"},{"name":"foo"}]}`,
+		`{"cities":[{"name":"foo","roads":[{"direction":"up","city":"bar"}]}]}`,
+		`{"cities":[{"name":"foo","roads":[{"direction":"north","city":"bar"},{"direction":"north","city":"baz"}]}]}`,
+		`{"cities":[{"name":"foo","roads":[{"direction":"north","city":""}]}]}`,
+		`{"cities":[{"name":"foo","roads":[{"direction":"north","city":"foo"}]}]}`,
+		`{"cities":[{"name":"foo"}],"aliens":[{"name":"alien1","city":"bar"}]}`,
+		`{"cities":[{"name":"foo"}],"aliens":[{"name":"alien1","city":"foo"},{"name":"alien1","city":"foo"}]}`,
+		`{"cities":[{"name":"foo"}],"aliens":[{"name":"a1","city":"foo"},{"name":"a2","city":"foo"},{"name":"a3","city":"foo"}]}`,
+		`{"cities":[{"name":"foo"}],"aliens":[{"name":"alien1","city":"foo","attributes":{"health":4294967296}}]}`,
+	}
+
+	for _, tc := range testCases {
+		m := buildMapFixtureEmpty()
+
+		if err := json.Unmarshal([]byte(tc), m); err == nil {
+			t.Errorf("expected error decoding: %s", tc)
+		}
+	}
+}
@@ -3,40 +3,109 @@ package world
 import (
 	"errors"
 	"fmt"
-	"log"
+	"sort"
 	"strings"
 
 	"github.com/alexanderbez/alien-invasion/queue"
+	"github.com/alexanderbez/alien-invasion/rng"
 )
 
 const (
-	// MaxOccupancy reflects the maximum number of aliens that may occupy any
-	// given city.
+	// MaxOccupancy reflects the default maximum number of aliens that may
+	// occupy any given city.
 	MaxOccupancy = 2
-	// MaxEdges reflects the maximum number of links (edges) from a city. Only
-	// north, south, east, and west links can be made.
+	// MaxEdges reflects the default maximum number of links (edges) from a
+	// city. Only north, south, east, and west links can be made.
 	MaxEdges = 4
 )
 
-// Map implements a representation of a world map. It's underlying
-// implementation is a directed graph. A list of city names are also tracked as
-// to be able to pseudo randomly pick cities.
+// ErrAliensTrapped is returned when no alien can be moved because every alien
+// is trapped in a city without any valid move.
+var ErrAliensTrapped = errors.New("unable to move any alien: all aliens are trapped")
+
+// Map implements a representation of a world map. Its underlying
+// implementation is a directed graph of cities bound by the rules of the map's
+// configuration. The order in which cities are added is tracked so that the
+// map can be serialized in the same order it was defined in. Aliens added to
+// the map take on their attributes from its roster, move as chosen by their
+// own or the map's default movement strategy and fight, in a city or on a road
+// they met on during a round, as decided by the map's fight resolver. All
+// pseudo randomness used when seeding, moving and fighting aliens is drawn
+// from the map's random number generator, so that a given map and seed always
+// result in the same simulation.
 type Map struct {
-	cities map[string]*City
-	aliens map[string]*Alien
+	cities          map[string]*City
+	cityOrder       []string
+	aliens          map[string]*Alien
+	mobile          []string
+	destroyed       []Destruction
+	collisions      []Collision
+	rng             *rng.Rand
+	config          Config
+	strategy        MovementStrategy
+	alienStrategies map[string]MovementStrategy
+	resolver        FightResolver
+	roster          Roster
+}
+
+// Destruction records a city that has been destroyed along with the names of
+// the aliens that destroyed it and the roads that were removed as a result.
+type Destruction struct {
+	City   string   `json:"city"`
+	Aliens []string `json:"aliens"`
+	Roads  []Road   `json:"roads"`
+}
+
+// Road reflects a link (directional edge) from one city to another in a given
+// direction.
+type Road struct {
+	From      string `json:"from"`
+	Direction string `json:"direction"`
+	To        string `json:"to"`
+}
+
+// Move reflects a single alien move from one city to another following the
+// road in the given direction. An alien that chose to stay in its city is
+// reflected by a move without a direction from and to that city.
+type Move struct {
+	Alien     string
+	From      string
+	To        string
+	Direction string
+}
+
+// Stayed returns a boolean on whether or not the alien stayed in its city
+// instead of moving.
+func (mv Move) Stayed() bool {
+	return len(mv.Direction) == 0
 }
 
 // City implements a city in a world map that contains a name, occupied aliens
 // and directional links (directional edges) to other cities by name both in
-// and out of the city.
+// and out of the city. Out links are keyed by direction while in links are
+// keyed by the name of the city the link originates from and count the number
+// of links from that city, as multiple cities may reach a city from the same
+// direction.
+//
+// The order in which out links are added is tracked in linkOrder so that the
+// city can be serialized with its links in the order they were defined in.
+// It may contain directions of links that have since been removed.
+//
+// A city is defined if it was added in its own right rather than only being
+// referenced by the links of other cities, so that cities that are only
+// referenced can be serialized just like they were defined.
 //
 // Note: We take the space overhead of storing inLinks in order to reduce time
 // overhead for certain operations.
 type City struct {
 	name           string
-	inLinks        map[string]string
+	inLinks        map[string]uint
 	outLinks       map[string]string
+	linkOrder      []string
 	alienOccupancy map[string]*Alien
+	damage         uint
+	defined        bool
+	road           []string
 }
 
 // Priority implements the Heapable interface.
@@ -48,30 +117,238 @@ func (c *City) Priority(other interface{}) bool {
 	return false
 }
 
-// String implements the Stringer interface.
+// String implements the Stringer interface. The city is formatted as a map
+// definition line with its links in the order they were added. A city without
+// any links is formatted as its name alone.
 func (c *City) String() string {
-	if len(c.outLinks) == 0 {
-		return ""
-	}
-
 	links := ""
 
-	for linkDir, linkCityName := range c.outLinks {
-		links += fmt.Sprintf(" %s=%s", linkDir, linkCityName)
+	for _, linkDir := range c.LinkDirections() {
+		links += fmt.Sprintf(" %s=%s", linkDir, c.outLinks[linkDir])
 	}
 
 	return fmt.Sprintf("%s%s", c.name, links)
 }
 
-// NewMap returns a reference to a new initialized Map.
-func NewMap() *Map {
+// Name returns the name of the city.
+func (c *City) Name() string {
+	return c.name
+}
+
+// Implicit returns a boolean on whether or not the city is only implied by the
+// links (edges) of other cities, i.e. it was never defined in its own right
+// and other cities still link to it.
+func (c *City) Implicit() bool {
+	return !c.defined && len(c.inLinks) != 0
+}
+
+// Road returns the names of the two cities linked by a road in name order if
+// the city is a placeholder for aliens fighting on that road, or nil
+// otherwise.
+func (c *City) Road() []string {
+	return c.road
+}
+
+// Damage returns the damage the city has taken in fights it survived.
+func (c *City) Damage() uint {
+	return c.damage
+}
+
+// AlienAttributes returns the attributes of the alien with the given name
+// occupying the city and a boolean on whether or not such an alien occupies
+// the city.
+func (c *City) AlienAttributes(alienName string) (Attributes, bool) {
+	alien, ok := c.alienOccupancy[alienName]
+	if !ok {
+		return Attributes{}, false
+	}
+
+	return alien.attrs, true
+}
+
+// AlienNames returns the names of all the aliens occupying the city sorted by
+// name.
+func (c *City) AlienNames() []string {
+	return sortedKeys(c.alienOccupancy)
+}
+
+// LinkDirections returns the directions of all the out links (outbound edges)
+// of the city in the order they were added.
+func (c *City) LinkDirections() []string {
+	linkDirs := make([]string, 0, len(c.outLinks))
+
+	for _, linkDir := range c.linkOrder {
+		if _, ok := c.outLinks[linkDir]; ok {
+			linkDirs = append(linkDirs, linkDir)
+		}
+	}
+
+	return linkDirs
+}
+
+// Link returns the name of the city the out link (outbound edge) in the given
+// direction leads to and a boolean on whether or not such a link exists.
+func (c *City) Link(linkDir string) (string, bool) {
+	linkCityName, ok := c.outLinks[linkDir]
+	return linkCityName, ok
+}
+
+// NewMap returns a reference to a new initialized Map that draws all of its
+// pseudo randomness from the given random number generator. The map uses the
+// default configuration, aliens move by a uniform random walk and fights
+// destroy both the aliens and their city by default.
+func NewMap(r *rng.Rand) *Map {
 	return &Map{
-		cities: make(map[string]*City),
-		aliens: make(map[string]*Alien),
+		cities:          make(map[string]*City),
+		aliens:          make(map[string]*Alien),
+		rng:             r,
+		config:          DefaultConfig(),
+		strategy:        RandomWalk{},
+		alienStrategies: make(map[string]MovementStrategy),
+		resolver:        DestroyAll{},
+	}
+}
+
+// NewMapWithConfig returns a reference to a new initialized Map just like
+// NewMap, but using the given configuration. An error is returned if the
+// configuration is invalid.
+func NewMapWithConfig(r *rng.Rand, cfg Config) (*Map, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := NewMap(r)
+	m.config = cfg
+
+	return m, nil
+}
+
+// Config returns the configuration of the map.
+func (m *Map) Config() Config {
+	return m.config
+}
+
+// Strategy returns the default movement strategy of the map.
+func (m *Map) Strategy() MovementStrategy {
+	return m.strategy
+}
+
+// SetStrategy sets the default movement strategy used by all aliens without a
+// movement strategy of their own.
+func (m *Map) SetStrategy(s MovementStrategy) {
+	m.strategy = s
+}
+
+// AlienStrategy returns the movement strategy used by the alien with the given
+// name, which is the map's default movement strategy unless one is set for the
+// alien.
+func (m *Map) AlienStrategy(alienName string) MovementStrategy {
+	if s, ok := m.alienStrategies[alienName]; ok {
+		return s
+	}
+
+	return m.strategy
+}
+
+// SetAlienStrategy sets the movement strategy used by the alien with the given
+// name. The alien need not exist yet, so that strategies can be set before
+// aliens are seeded. A nil strategy resets the alien to the map's default
+// movement strategy.
+func (m *Map) SetAlienStrategy(alienName string, s MovementStrategy) {
+	if s == nil {
+		delete(m.alienStrategies, alienName)
+		return
 	}
+
+	m.alienStrategies[alienName] = s
+}
+
+// FightResolver returns the fight resolver of the map.
+func (m *Map) FightResolver() FightResolver {
+	return m.resolver
+}
+
+// SetFightResolver sets the fight resolver used to resolve all fights in
+// cities.
+func (m *Map) SetFightResolver(r FightResolver) {
+	m.resolver = r
+}
+
+// Roster returns the roster of the map.
+func (m *Map) Roster() Roster {
+	return m.roster
+}
+
+// SetRoster sets the roster of the map. Aliens already on the map that are
+// listed in the roster take on their listed attributes. Aliens added later on
+// take on their listed attributes too while the attributes of any other seeded
+// alien are drawn from the roster's distribution of species. An error is
+// returned if the roster is invalid.
+func (m *Map) SetRoster(r Roster) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	m.roster = r
+
+	for alienName, attrs := range r.Aliens {
+		if alien, ok := m.aliens[alienName]; ok {
+			alien.attrs = attrs.withDefaults()
+		}
+	}
+
+	return nil
 }
 
-// AlienNames returns a unique list of all the aliens that exist in the map.
+// AlienAttributes returns the attributes of the alien with the given name and
+// a boolean on whether or not such an alien exists.
+func (m *Map) AlienAttributes(alienName string) (Attributes, bool) {
+	alien, ok := m.aliens[alienName]
+	if !ok {
+		return Attributes{}, false
+	}
+
+	return alien.attrs, true
+}
+
+// SetAlienAttributes sets the attributes of the alien with the given name.
+// Omitted strength, health and speed values default to one. An error is
+// returned if the alien does not exist or the attributes are invalid.
+func (m *Map) SetAlienAttributes(alienName string, attrs Attributes) error {
+	alien, ok := m.aliens[alienName]
+	if !ok {
+		return fmt.Errorf("alien %s does not exist", alienName)
+	}
+
+	if err := attrs.Validate(); err != nil {
+		return err
+	}
+
+	alien.attrs = attrs.withDefaults()
+	return nil
+}
+
+// Occupancy returns the number of aliens occupying the city with the given
+// name. It implements the MapView interface.
+func (m *Map) Occupancy(cityName string) int {
+	city, ok := m.cities[cityName]
+	if !ok {
+		return 0
+	}
+
+	return len(city.alienOccupancy)
+}
+
+// HasVisited returns a boolean on whether or not the alien with the given name
+// has ever occupied the city with the given name. It implements the MapView
+// interface.
+func (m *Map) HasVisited(alienName, cityName string) bool {
+	alien, ok := m.aliens[alienName]
+	return ok && alien.visited[cityName]
+}
+
+// AlienNames returns a unique list of all the aliens that exist in the map
+// sorted by name.
 func (m *Map) AlienNames() []string {
 	alienNames := make([]string, 0, len(m.aliens))
 
@@ -79,20 +356,52 @@ func (m *Map) AlienNames() []string {
 		alienNames = append(alienNames, alienName)
 	}
 
+	sort.Strings(alienNames)
 	return alienNames
 }
 
-// Cities returns all the cities in the map.
+// AlienCity returns the name of the city occupied by the alien with the given
+// name and a boolean on whether or not such an alien exists.
+func (m *Map) AlienCity(alienName string) (string, bool) {
+	alien, ok := m.aliens[alienName]
+	if !ok {
+		return "", false
+	}
+
+	return alien.cityName, true
+}
+
+// CityDamage returns the damage taken by the city with the given name and a
+// boolean on whether or not such a city exists.
+func (m *Map) CityDamage(cityName string) (uint, bool) {
+	city, ok := m.cities[cityName]
+	if !ok {
+		return 0, false
+	}
+
+	return city.damage, true
+}
+
+// Cities returns all the cities in the map in the order they were added.
 func (m *Map) Cities() []*City {
 	cities := make([]*City, 0, len(m.cities))
 
-	for _, city := range m.cities {
-		cities = append(cities, city)
+	for _, cityName := range m.CityNames() {
+		cities = append(cities, m.cities[cityName])
 	}
 
 	return cities
 }
 
+// DestroyedCities returns all the cities that have been destroyed by fighting
+// aliens in the order they were destroyed.
+func (m *Map) DestroyedCities() []Destruction {
+	destroyed := make([]Destruction, len(m.destroyed))
+	copy(destroyed, m.destroyed)
+
+	return destroyed
+}
+
 // NumCities returns the total number of unique cities in the Map.
 func (m *Map) NumCities() uint {
 	return uint(len(m.cities))
@@ -104,206 +413,631 @@ func (m *Map) NumAliens() uint {
 	return uint(len(m.aliens))
 }
 
-// CityNames returns a list of all the unique city names in the map.
+// IsTrapped returns a boolean on whether or not the alien with the given name
+// is trapped, i.e. it occupies a city without any out links (edges). Unless
+// links are added to its city, a trapped alien can never move again.
+func (m *Map) IsTrapped(alienName string) bool {
+	alien, ok := m.aliens[alienName]
+	return ok && alien.trapped
+}
+
+// IsImmobilised returns a boolean on whether or not the alien with the given
+// name currently has no valid move, i.e. it is trapped or all the cities its
+// city links to are at capacity.
+func (m *Map) IsImmobilised(alienName string) bool {
+	alien, ok := m.aliens[alienName]
+	if !ok {
+		return false
+	}
+
+	return alien.trapped || len(m.validMoves(alien)) == 0
+}
+
+// TrappedAliens returns the names of all the trapped aliens sorted by name.
+func (m *Map) TrappedAliens() []string {
+	var alienNames []string
+
+	for _, alienName := range m.AlienNames() {
+		if m.aliens[alienName].trapped {
+			alienNames = append(alienNames, alienName)
+		}
+	}
+
+	return alienNames
+}
+
+// CityNames returns a list of all the unique city names in the map in the
+// order they were added.
 func (m *Map) CityNames() []string {
 	cityNames := make([]string, 0, m.NumCities())
 
-	for cityName := range m.cities {
-		cityNames = append(cityNames, cityName)
+	// Destroyed cities are only removed from the map of cities.
+	for _, cityName := range m.cityOrder {
+		if _, ok := m.cities[cityName]; ok {
+			cityNames = append(cityNames, cityName)
+		}
 	}
 
 	return cityNames
 }
 
-// AddLink adds a link (directional edge) from an origin city to a linked city.
-// If the origin city or linked city do not exist in the graph, they are
-// initialized and added. Finally, the out link is added to the origin city and
-// the in link is added to the linked city.
-func (m *Map) AddLink(cityName, linkCityDir, linkCityName string) {
-	// Add the origin city to the map of cities
-	if _, ok := m.cities[cityName]; !ok {
-		m.cities[cityName] = &City{
-			name:           cityName,
-			inLinks:        make(map[string]string, MaxEdges),
-			outLinks:       make(map[string]string, MaxEdges),
-			alienOccupancy: make(map[string]*Alien, MaxOccupancy),
-		}
+// AddCity adds a city with the given name to the map if it does not already
+// exist. The city has no links (edges) until they are added via AddLink. A
+// city that was only referenced by the links of other cities so far is now
+// defined in its own right.
+func (m *Map) AddCity(cityName string) {
+	m.addCity(cityName)
+	m.cities[cityName].defined = true
+}
+
+// addCity adds a city with the given name to the map if it does not already
+// exist without defining it.
+func (m *Map) addCity(cityName string) {
+	if _, ok := m.cities[cityName]; ok {
+		return
+	}
+
+	m.cities[cityName] = &City{
+		name:           cityName,
+		inLinks:        make(map[string]uint, m.config.MaxEdges),
+		outLinks:       make(map[string]string, m.config.MaxEdges),
+		linkOrder:      make([]string, 0, m.config.MaxEdges),
+		alienOccupancy: make(map[string]*Alien, m.config.Capacity),
 	}
+	m.cityOrder = append(m.cityOrder, cityName)
+}
+
+// AddLink adds a link (directional edge) from an origin city to a linked city
+// in the given direction, which is converted to lower case. If the origin
+// city or linked city do not exist in the graph, they are initialized and
+// added, where only the origin city is defined. Finally, the out link is added
+// to the origin city and the in link is added to the linked city. Any
+// existing link from the origin city in the same direction is replaced. An
+// error is returned if the origin city already has the maximum number of
+// links in other directions.
+func (m *Map) AddLink(cityName, linkCityDir, linkCityName string) error {
+	linkDir := strings.ToLower(linkCityDir)
 
-	// Add the linked city to the map of cities
-	if _, ok := m.cities[linkCityName]; !ok {
-		m.cities[linkCityName] = &City{
-			name:           linkCityName,
-			inLinks:        make(map[string]string, MaxEdges),
-			outLinks:       make(map[string]string, MaxEdges),
-			alienOccupancy: make(map[string]*Alien, MaxOccupancy),
+	if city, ok := m.cities[cityName]; ok {
+		_, replaced := city.outLinks[linkDir]
+
+		if !replaced && uint(len(city.outLinks)) >= m.config.MaxEdges {
+			return fmt.Errorf("city %s already has the maximum of %d roads", cityName, m.config.MaxEdges)
 		}
 	}
 
+	m.AddCity(cityName)
+	m.addCity(linkCityName)
+
+	city := m.cities[cityName]
+
+	m.removeLink(city, linkDir)
+
+	if !containsString(city.linkOrder, linkDir) {
+		city.linkOrder = append(city.linkOrder, linkDir)
+	}
+
 	// Add outbound and inbound links (directional edges)
-	m.cities[cityName].outLinks[strings.ToLower(linkCityDir)] = linkCityName
-	m.cities[linkCityName].inLinks[strings.ToLower(linkCityDir)] = cityName
+	city.outLinks[linkDir] = linkCityName
+	m.cities[linkCityName].inLinks[cityName]++
+
+	m.updateTrapped(city)
+	return nil
+}
+
+// removeLink removes the out link (directional edge) of a city in the given
+// direction, if any, along with the matching in link of the linked city.
+func (m *Map) removeLink(city *City, linkDir string) {
+	linkCityName, ok := city.outLinks[linkDir]
+	if !ok {
+		return
+	}
+
+	delete(city.outLinks, linkDir)
+
+	linkCity := m.cities[linkCityName]
+	if linkCity.inLinks[city.name] <= 1 {
+		delete(linkCity.inLinks, city.name)
+	} else {
+		linkCity.inLinks[city.name]--
+	}
+}
+
+// AddAlien places a new alien with the given name in the city with the given
+// name. The alien takes on its attributes listed in the map's roster, if any,
+// or the default attributes otherwise. An error is returned if an alien with
+// the same name already exists, the city does not exist or the city is already
+// at maximum occupancy.
+func (m *Map) AddAlien(alienName, cityName string) error {
+	if _, ok := m.aliens[alienName]; ok {
+		return fmt.Errorf("alien %s already exists", alienName)
+	}
+
+	city, ok := m.cities[cityName]
+	if !ok {
+		return fmt.Errorf("city %s does not exist", cityName)
+	}
+
+	if uint(len(city.alienOccupancy)) >= m.config.Capacity {
+		return fmt.Errorf("city %s is already occupied by %d aliens", cityName, len(city.alienOccupancy))
+	}
+
+	alien := newAlien(alienName, cityName)
+	if attrs, ok := m.roster.Aliens[alienName]; ok {
+		alien.attrs = attrs.withDefaults()
+	}
+
+	city.alienOccupancy[alien.name] = alien
+	m.aliens[alien.name] = alien
+
+	m.updateTrapped(city)
+	return nil
 }
 
 // MoveAlien attempts to move an alien on the map from one city to another
-// following a valid direction. The algorithm for finding a valid move follows:
+// following a valid direction. A move is valid if the alien's city has an out
+// link (edge) leading to a city that has space for an additional alien. The
+// move is chosen as follows:
 //
-// 1. Find an alien that occupies a city with at least one valid out link (edge)
-// 2. If that link leads to a city that has space for an additional alien, then:
-// 2a. Update the alien's city
-// 2b. Remove alien from current city
-// 2c. Add alien to new city
-// 3. Otherwise, continue evaluating other out links. If no links are valid,
-// then try another alien.
+// 1. Pick an alien at random among all aliens that have at least one valid
+// move, weighted by the aliens' speed.
+// 2. Let the movement strategy of that alien choose one of its valid moves or
+// to stay in its city.
+// 3. Remove the alien from its current city and add it to the linked city,
+// unless it stays.
 //
-// If no alien can be moved, an error is returned. Otherwise, the name of the
-// moved alien is returned.
-func (m *Map) MoveAlien() (string, error) {
-	// We will get some pseudo randomness iterating over the city's list of
-	// aliens and out links.
-	for _, alien := range m.aliens {
-		occupiedCity := alien.cityName
-		city := m.cities[occupiedCity]
+// In other words, with the default uniform random walk and given k movable
+// aliens of the default speed where alien i has d(i) valid directions, a
+// particular direction of alien i is chosen with probability 1/(k*d(i)).
+// Generally, alien i of speed s(i) is chosen with probability s(i)/S where S
+// is the total speed of all movable aliens. If no alien can be moved,
+// ErrAliensTrapped is returned. An error is returned if the movement strategy
+// chooses an invalid direction. Otherwise, the move made is returned.
+func (m *Map) MoveAlien() (Move, error) {
+	alien, linkDirs, ok := m.chooseAlien()
+	if !ok {
+		return Move{}, ErrAliensTrapped
+	}
 
-		for _, linkCityName := range city.outLinks {
-			linkCity := m.cities[linkCityName]
+	strategy := m.AlienStrategy(alien.name)
 
-			if len(linkCity.alienOccupancy) < MaxOccupancy {
-				delete(city.alienOccupancy, alien.name)
+	linkDir := strategy.ChooseMove(alien.name, m.cities[alien.cityName], linkDirs, m, m.rng)
+	if len(linkDir) == 0 {
+		return Move{Alien: alien.name, From: alien.cityName, To: alien.cityName}, nil
+	}
 
-				alien.cityName = linkCity.name
-				linkCity.alienOccupancy[alien.name] = alien
+	if !containsString(linkDirs, linkDir) {
+		return Move{}, fmt.Errorf("movement strategy %s chose invalid direction %q for alien %s", strategy, linkDir, alien.name)
+	}
 
-				return alien.name, nil
-			}
+	return m.moveAlien(alien, linkDir), nil
+}
+
+// ApplyMove moves the alien with the given name following the out link (edge)
+// in the given direction. It allows a specific move, e.g. one that was
+// previously recorded, to be made instead of a random one. An error is
+// returned if the alien does not exist, its city has no link in the given
+// direction or the linked city is already at maximum occupancy. Otherwise,
+// the move made is returned.
+func (m *Map) ApplyMove(alienName, linkDir string) (Move, error) {
+	alien, ok := m.aliens[alienName]
+	if !ok {
+		return Move{}, fmt.Errorf("alien %s does not exist", alienName)
+	}
+
+	city := m.cities[alien.cityName]
+
+	linkCityName, ok := city.outLinks[linkDir]
+	if !ok {
+		return Move{}, fmt.Errorf("city %s has no road to the %s", city.name, linkDir)
+	}
+
+	if uint(len(m.cities[linkCityName].alienOccupancy)) >= m.config.Capacity {
+		return Move{}, fmt.Errorf("city %s is already at maximum occupancy", linkCityName)
+	}
+
+	return m.moveAlien(alien, linkDir), nil
+}
+
+// moveAlien moves an alien from its city to the city linked in the given
+// direction. It is assumed the move is valid.
+func (m *Map) moveAlien(alien *Alien, linkDir string) Move {
+	city := m.cities[alien.cityName]
+	linkCity := m.cities[city.outLinks[linkDir]]
+
+	delete(city.alienOccupancy, alien.name)
+
+	alien.cityName = linkCity.name
+	m.setTrapped(alien, len(linkCity.outLinks) == 0)
+	alien.visited[linkCity.name] = true
+	linkCity.alienOccupancy[alien.name] = alien
+
+	return Move{Alien: alien.name, From: city.name, To: linkCity.name, Direction: linkDir}
+}
+
+// chooseAlien selects a random alien among all aliens that have at least one
+// valid move, weighted by their speed. It returns the alien, its valid
+// directions and a boolean on whether or not any alien has a valid move.
+func (m *Map) chooseAlien() (*Alien, []string, bool) {
+	var (
+		movable   []*Alien
+		validDirs [][]string
+	)
+
+	// Trapped aliens can never move, so there is no need to examine them.
+	for _, alienName := range m.mobile {
+		alien := m.aliens[alienName]
+
+		if linkDirs := m.validMoves(alien); len(linkDirs) != 0 {
+			movable = append(movable, alien)
+			validDirs = append(validDirs, linkDirs)
+		}
+	}
+
+	if len(movable) == 0 {
+		return nil, nil, false
+	}
+
+	// With every alien at the default speed of one, this draws exactly like
+	// choosing uniformly among the movable aliens.
+	var total uint64
+	for _, alien := range movable {
+		total += uint64(alien.attrs.Speed)
+	}
+
+	i, w := 0, m.rng.Uint64n(total)
+	for w >= uint64(movable[i].attrs.Speed) {
+		w -= uint64(movable[i].attrs.Speed)
+		i++
+	}
+
+	return movable[i], validDirs[i], true
+}
+
+// validMoves returns the sorted list of directions an alien may currently move
+// in. A direction is valid if it leads to a city with space for an additional
+// alien.
+func (m *Map) validMoves(alien *Alien) []string {
+	city := m.cities[alien.cityName]
+	linkDirs := make([]string, 0, len(city.outLinks))
+
+	for _, linkDir := range sortedKeys(city.outLinks) {
+		linkCity := m.cities[city.outLinks[linkDir]]
+
+		if uint(len(linkCity.alienOccupancy)) < m.config.Capacity {
+			linkDirs = append(linkDirs, linkDir)
 		}
 	}
 
-	return "", errors.New("unable to move any alien")
+	return linkDirs
 }
 
 // destroyCity removes a given city from the map (directed graph) in addition
 // to any links (edges) that lead into or out of it. The aliens that occupy the
-// city are also destroyed. The resulting list of destroyed aliens is returned.
-func (m *Map) destroyCity(city *City) []string {
-	destroyedAliens := make([]string, 0, MaxOccupancy)
+// city are also destroyed. The resulting destruction, containing the list of
+// destroyed aliens and removed roads, is returned.
+func (m *Map) destroyCity(city *City) Destruction {
+	d := Destruction{
+		City:   city.name,
+		Aliens: city.AlienNames(),
+		Roads:  m.removeRoads(city),
+	}
 
-	for alienName := range city.alienOccupancy {
-		destroyedAliens = append(destroyedAliens, alienName)
-		delete(m.aliens, alienName)
+	for _, alienName := range d.Aliens {
+		m.removeAlien(alienName)
 	}
 
-	// Remove the destroyed city from all inks (inbound and outbound edges) from
-	// any city that can get to the destroyed city.
-	for _, inCityLinkName := range city.inLinks {
-		inCityLink := m.cities[inCityLinkName]
+	delete(m.cities, city.name)
+	return d
+}
 
-		for linkDir, linkCityName := range inCityLink.outLinks {
-			if linkCityName == city.name {
-				delete(inCityLink.outLinks, linkDir)
-				break
-			}
+// removeRoads removes any links (edges) that lead into or out of a given city
+// and returns the removed roads. Any aliens occupying the city or a city that
+// could get to it may now be trapped.
+func (m *Map) removeRoads(city *City) []Road {
+	var roads []Road
+
+	// Remove every out link (outbound edge) leading to the city from any city
+	// that can get to it.
+	for _, inCityName := range sortedKeys(city.inLinks) {
+		inCity := m.cities[inCityName]
+		if inCity == city {
+			continue
 		}
 
-		for linkDir, linkCityName := range inCityLink.inLinks {
-			if linkCityName == city.name {
-				delete(inCityLink.inLinks, linkDir)
-				break
+		for _, linkDir := range inCity.LinkDirections() {
+			if inCity.outLinks[linkDir] == city.name {
+				m.removeLink(inCity, linkDir)
+				roads = append(roads, Road{From: inCity.name, Direction: linkDir, To: city.name})
 			}
 		}
+
+		m.updateTrapped(inCity)
 	}
 
-	// Remove the destroyed city from any in links (inbound edges) from any city
-	// that can get to the destroyed city.
-	for _, outCityLinkName := range city.outLinks {
-		outCityLink := m.cities[outCityLinkName]
+	for _, linkDir := range city.LinkDirections() {
+		roads = append(roads, Road{From: city.name, Direction: linkDir, To: city.outLinks[linkDir]})
+		m.removeLink(city, linkDir)
+	}
 
-		for linkDir, linkCityName := range outCityLink.inLinks {
-			if linkCityName == city.name {
-				delete(outCityLink.inLinks, linkDir)
-				break
-			}
+	m.updateTrapped(city)
+	return roads
+}
+
+// ExecuteFights executes all fights just like ResolveFights. The resulting city
+// destructions are returned in the order they happened.
+func (m *Map) ExecuteFights() []Destruction {
+	var destroyed []Destruction
+
+	for _, f := range m.ResolveFights() {
+		if f.Destroyed {
+			destroyed = append(destroyed, Destruction{City: f.City, Aliens: f.Killed, Roads: f.Roads})
 		}
 	}
 
-	delete(m.cities, city.name)
-	return destroyedAliens
-}
-
-// ExecuteFights simulates a fight between any two aliens if there are any
-// found occupying a city. All the aliens are examined along with the city they
-// occupy. If any such city is occupied by MaxOccupancy, a fight is simulated
-// and the aliens along with the city are destroyed. In addition, any links
-// (edges) that lead into or out of the destroyed city are also removed from
-// the map.
-func (m *Map) ExecuteFights() {
-	for _, alien := range m.aliens {
-		occupiedCity := alien.cityName
-		city := m.cities[occupiedCity]
-
-		// If maximum occupancy has been reached for a city, the occupying
-		// aliens will fight and destroy the city. As a result, the following
-		// will happen:
-		//
-		// 1. Both aliens will be removed from the map's known collection of
-		// aliens.
-		// 2. The city will be removed from the map and so are any links that
-		// lead into or out of it.
-		if len(city.alienOccupancy) == MaxOccupancy {
-			destroyedAliens := m.destroyCity(city)
-			log.Printf("%s has been destroyed by %s!", city.name, strings.Join(destroyedAliens, " and "))
+	return destroyed
+}
+
+// ResolveFights simulates a fight between any aliens found occupying the same
+// city. Any aliens that met on a road during a round fight there first, their
+// fights decided by the map's fight resolver while leaving both cities and the
+// road intact. Surviving aliens remain in their city. Then, all the remaining
+// aliens are examined along with the city they occupy. If any such city is
+// occupied by at least the fight threshold of aliens, a fight is simulated and
+// its outcome decided by the map's fight resolver. Every city hosts at most one
+// fight per call. Depending on the outcome:
+//
+// 1. The killed aliens, including any alien that lost all of its health, are
+// removed from the map's known collection of aliens. Any other injured alien
+// loses health.
+// 2. If the city is destroyed, all of its aliens are killed and the city is
+// removed from the map and so are any links (edges) that lead into or out of
+// it. Otherwise, it takes the given damage and, if so decided, loses all the
+// links (edges) that lead into or out of it.
+//
+// The resulting fights are returned in the order they happened.
+func (m *Map) ResolveFights() []Fight {
+	fights := m.resolveCollisions()
+
+	fought := make(map[string]bool)
+
+	for _, alienName := range m.AlienNames() {
+		// The alien may have already been destroyed in a previous fight.
+		alien, ok := m.aliens[alienName]
+		if !ok {
+			continue
+		}
+
+		city := m.cities[alien.cityName]
+		if fought[city.name] || uint(len(city.alienOccupancy)) < m.config.FightThreshold {
+			continue
+		}
+
+		fought[city.name] = true
+		fights = append(fights, m.fight(city))
+	}
+
+	return fights
+}
+
+// fight resolves a fight between all the aliens occupying a given city using
+// the map's fight resolver and applies its outcome.
+func (m *Map) fight(city *City) Fight {
+	alienNames := city.AlienNames()
+	outcome := m.resolver.ResolveFight(city, alienNames, m.rng)
+
+	if outcome.DestroyCity {
+		d := m.destroyCity(city)
+		m.destroyed = append(m.destroyed, d)
+
+		return Fight{City: d.City, Aliens: d.Aliens, Killed: d.Aliens, Destroyed: true, Roads: d.Roads}
+	}
+
+	f := Fight{City: city.name, Aliens: alienNames, Damage: outcome.Damage}
+	m.applyCasualties(&f, outcome)
+
+	city.damage += outcome.Damage
+
+	if outcome.DestroyRoads {
+		f.Roads = m.removeRoads(city)
+	}
+
+	return f
+}
+
+// applyCasualties kills and injures the aliens that took part in the given
+// fight as decided by its outcome and records them in the fight. An alien that
+// loses all of its health is killed.
+func (m *Map) applyCasualties(f *Fight, outcome FightOutcome) {
+	killed := make(map[string]bool, len(outcome.Killed))
+	for _, alienName := range outcome.Killed {
+		killed[alienName] = true
+	}
+
+	for _, alienName := range f.Aliens {
+		alien := m.aliens[alienName]
+
+		if injury := outcome.Injuries[alienName]; !killed[alienName] && injury != 0 {
+			if injury < alien.attrs.Health {
+				alien.attrs.Health -= injury
+
+				if f.Injuries == nil {
+					f.Injuries = make(map[string]uint)
+				}
+
+				f.Injuries[alienName] = injury
+				continue
+			}
+
+			killed[alienName] = true
+		}
+
+		if killed[alienName] {
+			delete(m.cities[alien.cityName].alienOccupancy, alienName)
+			m.removeAlien(alienName)
+			f.Killed = append(f.Killed, alienName)
 		}
 	}
 }
 
 // SeedAliens adds n aliens to the world map at pseudo random cities. At most
-// 'MaxOccupancy' aliens can occupy a city at any given time. It is assumed the
-// number of aliens to seed is valid and as such each alien will find a valid
-// city to occupy. Alien occupancy is preferred in cities with out roads
-// (out edges).
-func (m *Map) SeedAliens(n uint) {
+// the configured capacity of aliens can occupy a city at any given time,
+// including any aliens already on the map. Alien occupancy is preferred in
+// cities with out roads (out edges). Seeded aliens are named alien1, alien2
+// and so on, skipping any name already taken. Every seeded alien takes on its
+// attributes listed in the map's roster, if any, or attributes drawn from the
+// roster's distribution of species otherwise. The names of the seeded aliens
+// are returned in the order they were seeded. An error is returned, and no
+// alien is seeded, if the cities do not have space for n more aliens.
+func (m *Map) SeedAliens(n uint) ([]string, error) {
+	var space uint
+	for _, city := range m.cities {
+		if occupancy := uint(len(city.alienOccupancy)); occupancy < m.config.Capacity {
+			space += m.config.Capacity - occupancy
+		}
+	}
+
+	if n > space {
+		return nil, fmt.Errorf("unable to seed %d aliens: cities only have space for %d more aliens", n, space)
+	}
+
 	pq := queue.NewPriorityQueue()
 
 	// Add all the cities pseudo-randomly to a priority queue. Priority is
 	// based on the total number of out degree links of a city.
-	for _, city := range m.cities {
-		pq.Push(city)
+	cities := m.Cities()
+	for _, i := range m.rng.Perm(len(cities)) {
+		pq.Push(cities[i])
 	}
 
-	// We assume the invariant that there are enough cities to occupy all 'n'
-	// aliens.
-	seededAliens := uint(0)
-	for seededAliens != n {
+	alienNames := make([]string, 0, n)
+	nextID := 1
+
+	for uint(len(alienNames)) != n {
 		city := pq.Pop().(*City)
 
-		for i := 0; i < MaxOccupancy && seededAliens != n; i++ {
-			alien := &Alien{
-				name:     fmt.Sprintf("alien%d", seededAliens+1),
-				cityName: city.name,
+		for uint(len(city.alienOccupancy)) < m.config.Capacity && uint(len(alienNames)) != n {
+			alienName := fmt.Sprintf("alien%d", nextID)
+			nextID++
+
+			if _, ok := m.aliens[alienName]; ok {
+				continue
+			}
+
+			alien := newAlien(alienName, city.name)
+
+			if attrs, ok := m.roster.Aliens[alien.name]; ok {
+				alien.attrs = attrs.withDefaults()
+			} else {
+				alien.attrs = m.roster.drawAttributes(m.rng)
 			}
 
 			city.alienOccupancy[alien.name] = alien
 			m.aliens[alien.name] = alien
+			alienNames = append(alienNames, alien.name)
 
-			seededAliens++
+			m.updateTrapped(city)
 		}
 	}
+
+	return alienNames, nil
+}
+
+// updateTrapped updates whether or not the aliens occupying the given city are
+// trapped in it.
+func (m *Map) updateTrapped(city *City) {
+	trapped := len(city.outLinks) == 0
+
+	for _, alien := range city.alienOccupancy {
+		m.setTrapped(alien, trapped)
+	}
+}
+
+// setTrapped updates whether or not the given alien is trapped and keeps the
+// sorted names of the aliens that are not trapped up to date.
+func (m *Map) setTrapped(alien *Alien, trapped bool) {
+	alien.trapped = trapped
+
+	i := sort.SearchStrings(m.mobile, alien.name)
+	tracked := i < len(m.mobile) && m.mobile[i] == alien.name
+
+	switch {
+	case trapped && tracked:
+		m.mobile = append(m.mobile[:i], m.mobile[i+1:]...)
+
+	case !trapped && !tracked:
+		m.mobile = append(m.mobile, "")
+		copy(m.mobile[i+1:], m.mobile[i:])
+		m.mobile[i] = alien.name
+	}
+}
+
+// removeAlien removes the alien with the given name from the map. It is
+// assumed the alien no longer occupies its city.
+func (m *Map) removeAlien(alienName string) {
+	if alien, ok := m.aliens[alienName]; ok {
+		m.setTrapped(alien, true)
+		delete(m.aliens, alienName)
+	}
 }
 
 // String implements the stringer interface.
 func (m *Map) String() (s string) {
-	for _, city := range m.cities {
-		aliens := make([]string, 0, len(city.alienOccupancy))
-
-		for alien := range city.alienOccupancy {
-			aliens = append(aliens, alien)
-		}
+	for _, city := range m.Cities() {
+		aliens := city.AlienNames()
 
 		s += fmt.Sprintf(
-			"{city: %s, outLinks: %s, inLinks: %s, alienOccupancy: [%s]}\n",
+			"{city: %s, outLinks: %s, inLinks: %v, alienOccupancy: [%s]}\n",
 			city.name, city.outLinks, city.inLinks, strings.Join(aliens, " "),
 		)
 	}
 
 	return
 }
+
+// containsString returns a boolean on whether or not a list of strings
+// contains the given string.
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedKeys returns the keys of a map keyed by strings in sorted order. It is
+// used to iterate over links and occupants in a deterministic order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	switch t := m.(type) {
+	case map[string]string:
+		keys = make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+
+	case map[string]uint:
+		keys = make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+
+	case map[string]*Alien:
+		keys = make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+
+	case map[string]bool:
+		keys = make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
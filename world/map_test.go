@@ -4,39 +4,52 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
 )
 
 func buildMapFixtureEmpty() *Map {
-	return NewMap()
+	return NewMap(rng.NewRand(1))
 }
 
 func buildMapFixtureSimple() *Map {
-	a1 := &Alien{name: "alien1", cityName: "foo"}
-	a2 := &Alien{name: "alien2", cityName: "foo"}
-	a3 := &Alien{name: "alien3", cityName: "bar"}
-	a4 := &Alien{name: "alien4", cityName: "bar"}
+	a1 := newAlien("alien1", "foo")
+	a2 := newAlien("alien2", "foo")
+	a3 := newAlien("alien3", "bar")
+	a4 := newAlien("alien4", "bar")
 
 	m := &Map{
+		rng:             rng.NewRand(1),
+		config:          DefaultConfig(),
+		strategy:        RandomWalk{},
+		alienStrategies: make(map[string]MovementStrategy),
+		resolver:        DestroyAll{},
+		cityOrder:       []string{"foo", "bar"},
 		aliens: map[string]*Alien{
 			a1.name: a1,
 			a2.name: a2,
 			a3.name: a3,
 			a4.name: a4,
 		},
+		mobile: []string{a1.name, a2.name, a3.name, a4.name},
 		cities: map[string]*City{
 			"foo": &City{
-				name:     "foo",
-				inLinks:  map[string]string{"south": "bar"},
-				outLinks: map[string]string{"north": "bar"},
+				name:      "foo",
+				inLinks:   map[string]uint{"bar": 1},
+				outLinks:  map[string]string{"north": "bar"},
+				linkOrder: []string{"north"},
+				defined:   true,
 				alienOccupancy: map[string]*Alien{
 					a1.name: a1,
 					a2.name: a2,
 				},
 			},
 			"bar": &City{
-				name:     "bar",
-				inLinks:  map[string]string{"north": "foo"},
-				outLinks: map[string]string{"south": "foo"},
+				name:      "bar",
+				inLinks:   map[string]uint{"foo": 1},
+				outLinks:  map[string]string{"south": "foo"},
+				linkOrder: []string{"south"},
+				defined:   true,
 				alienOccupancy: map[string]*Alien{
 					a3.name: a3,
 					a4.name: a4,
@@ -191,17 +204,99 @@ func TestAddLink(t *testing.T) {
 		t.Errorf("expected %s city to have valid out links: %v", "foo", e)
 	}
 
-	e = map[string]string{"north": "foo"}
-	if !reflect.DeepEqual(m1.cities["bar"].inLinks, e) {
-		t.Errorf("expected %s linked city to have valid in links: %v", "bar", e)
+	ei := map[string]uint{"foo": 1}
+	if !reflect.DeepEqual(m1.cities["bar"].inLinks, ei) {
+		t.Errorf("expected %s linked city to have valid in links: %v", "bar", ei)
+	}
+
+	// Replacing a link must remove the stale in link of the previously linked
+	// city.
+	m1.AddLink("foo", "north", "baz")
+
+	if len(m1.cities["bar"].inLinks) != 0 {
+		t.Errorf("expected %s to have no in links: %v", "bar", m1.cities["bar"].inLinks)
+	}
+
+	checkLinks(t, m1)
+}
+
+// checkLinks asserts that every link (edge) in the map references an existing
+// city and that the in links of every city exactly mirror the out links of
+// the cities leading to it.
+func checkLinks(t *testing.T, m *Map) {
+	t.Helper()
+
+	e := make(map[string]map[string]uint, len(m.cities))
+	for cityName := range m.cities {
+		e[cityName] = make(map[string]uint)
+	}
+
+	for cityName, city := range m.cities {
+		for linkDir, linkCityName := range city.outLinks {
+			if _, ok := m.cities[linkCityName]; !ok {
+				t.Errorf("dangling out link: %s %s=%s", cityName, linkDir, linkCityName)
+				continue
+			}
+
+			e[linkCityName][cityName]++
+		}
+	}
+
+	for cityName, city := range m.cities {
+		for inCityName := range city.inLinks {
+			if _, ok := m.cities[inCityName]; !ok {
+				t.Errorf("dangling in link: %s <- %s", cityName, inCityName)
+			}
+		}
+
+		if !reflect.DeepEqual(city.inLinks, e[cityName]) {
+			t.Errorf("incorrect in links for %s: expected: %v, got: %v", cityName, e[cityName], city.inLinks)
+		}
+	}
+
+	for alienName, alien := range m.aliens {
+		city, ok := m.cities[alien.cityName]
+		if !ok {
+			t.Errorf("alien %s occupies destroyed city %s", alienName, alien.cityName)
+			continue
+		}
+
+		if city.alienOccupancy[alienName] != alien {
+			t.Errorf("alien %s missing from occupancy of city %s", alienName, alien.cityName)
+		}
+	}
+}
+
+func TestCityString(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "west", "baz")
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "east", "qux")
+	m.AddLink("foo", "north", "bee")
+
+	e := "foo west=baz north=bee east=qux"
+	if r := m.cities["foo"].String(); r != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+
+	m.destroyCity(m.cities["baz"])
+
+	e = "foo north=bee east=qux"
+	if r := m.cities["foo"].String(); r != e {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+
+	if r := m.cities["bar"].String(); r != "bar" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "bar", r)
 	}
 }
 
 func TestMoveAlien(t *testing.T) {
 	m1 := buildMapFixtureEmpty()
 
-	if _, err := m1.MoveAlien(); err == nil {
-		t.Errorf("expected error: no aliens to move in empty map")
+	if _, err := m1.MoveAlien(); err != ErrAliensTrapped {
+		t.Errorf("incorrect result: expected: %v, got: %v", ErrAliensTrapped, err)
 	}
 
 	m2 := buildMapFixtureSimple()
@@ -212,10 +307,71 @@ func TestMoveAlien(t *testing.T) {
 	}
 }
 
+func TestMoveAlienUniform(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	// alien1 can move in three directions, alien2 in a single direction and
+	// alien3 is blocked by a fully occupied city.
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "south", "baz")
+	m.AddLink("foo", "east", "qux")
+	m.AddLink("bee", "west", "foo")
+	m.AddLink("full", "north", "fuller")
+
+	placements := map[string]string{
+		"alien1": "foo",
+		"alien2": "bee",
+		"alien3": "full",
+		"alien4": "fuller",
+		"alien5": "fuller",
+	}
+
+	for alienName, cityName := range placements {
+		a := newAlien(alienName, cityName)
+		m.aliens[alienName] = a
+		m.cities[cityName].alienOccupancy[alienName] = a
+		m.updateTrapped(m.cities[cityName])
+	}
+
+	const trials = 60000
+
+	counts := make(map[string]int)
+	for i := 0; i < trials; i++ {
+		a, linkDirs, ok := m.chooseAlien()
+		if !ok {
+			t.Fatalf("expected a valid move to exist")
+		}
+
+		linkDir := m.AlienStrategy(a.name).ChooseMove(a.name, m.cities[a.cityName], linkDirs, m, m.rng)
+
+		counts[a.name+" "+linkDir]++
+	}
+
+	e := map[string]float64{
+		"alien1 east":  1.0 / 6,
+		"alien1 north": 1.0 / 6,
+		"alien1 south": 1.0 / 6,
+		"alien2 west":  1.0 / 2,
+	}
+
+	if len(counts) != len(e) {
+		t.Fatalf("incorrect result: expected moves: %v, got: %v", e, counts)
+	}
+
+	for move, p := range e {
+		r := float64(counts[move]) / trials
+
+		if r < p-0.02 || r > p+0.02 {
+			t.Errorf("incorrect frequency for move %s: expected: %v, got: %v", move, p, r)
+		}
+	}
+}
+
 func TestDestroyCity(t *testing.T) {
 	m := buildMapFixtureSimple()
 	c := m.cities["foo"]
-	r := m.destroyCity(c)
+	d := m.destroyCity(c)
+	r := d.Aliens
 
 	e := make([]string, 0, MaxOccupancy)
 	for k := range c.alienOccupancy {
@@ -249,9 +405,77 @@ func TestDestroyCity(t *testing.T) {
 	}
 }
 
+func TestDestroyCityConvergingRoads(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	// Both foo and bar reach baz from the same direction while qux reaches it
+	// from two directions.
+	m.AddLink("foo", "north", "baz")
+	m.AddLink("bar", "north", "baz")
+	m.AddLink("qux", "east", "baz")
+	m.AddLink("qux", "west", "baz")
+	m.AddLink("qux", "north", "foo")
+	m.AddLink("baz", "south", "foo")
+	m.AddLink("baz", "west", "bar")
+	m.AddLink("foo", "east", "bar")
+
+	e := map[string]uint{"foo": 1, "bar": 1, "qux": 2}
+	if !reflect.DeepEqual(m.cities["baz"].inLinks, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.cities["baz"].inLinks)
+	}
+
+	m.destroyCity(m.cities["baz"])
+	checkLinks(t, m)
+
+	for _, cityName := range []string{"foo", "bar", "qux"} {
+		for linkDir, linkCityName := range m.cities[cityName].outLinks {
+			if linkCityName == "baz" {
+				t.Errorf("expected %s %s=%s to be removed", cityName, linkDir, linkCityName)
+			}
+		}
+	}
+
+	// Aliens must still be able to move without referencing the destroyed
+	// city.
+	a := newAlien("alien1", "qux")
+	m.aliens[a.name] = a
+	m.cities["qux"].alienOccupancy[a.name] = a
+	m.updateTrapped(m.cities["qux"])
+
+	if _, err := m.MoveAlien(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDestroyCityRandomGraphs(t *testing.T) {
+	r := rng.NewRand(7)
+	cityNames := []string{"a", "b", "c", "d", "e", "f"}
+
+	for i := 0; i < 200; i++ {
+		m := buildMapFixtureEmpty()
+
+		for _, cityName := range cityNames {
+			for _, linkDir := range Directions {
+				if r.Intn(2) == 0 {
+					m.AddLink(cityName, linkDir, cityNames[r.Intn(len(cityNames))])
+				}
+			}
+		}
+
+		checkLinks(t, m)
+
+		for _, j := range r.Perm(len(cityNames))[:3] {
+			if city, ok := m.cities[cityNames[j]]; ok {
+				m.destroyCity(city)
+				checkLinks(t, m)
+			}
+		}
+	}
+}
+
 func TestExecuteFights(t *testing.T) {
 	m := buildMapFixtureSimple()
-	m.ExecuteFights()
+	r := m.ExecuteFights()
 
 	if len(m.cities) != 0 {
 		t.Errorf("expected map to have no remaining cities: cities: %v", m.cities)
@@ -260,6 +484,26 @@ func TestExecuteFights(t *testing.T) {
 	if len(m.aliens) != 0 {
 		t.Errorf("expected map to have no remaining aliens: aliens: %v", m.aliens)
 	}
+
+	e := []Destruction{
+		{
+			City:   "foo",
+			Aliens: []string{"alien1", "alien2"},
+			Roads: []Road{
+				{From: "bar", Direction: "south", To: "foo"},
+				{From: "foo", Direction: "north", To: "bar"},
+			},
+		},
+		{City: "bar", Aliens: []string{"alien3", "alien4"}},
+	}
+
+	if !reflect.DeepEqual(r, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
+
+	if r := m.DestroyedCities(); !reflect.DeepEqual(r, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, r)
+	}
 }
 
 func TestSeedAliens(t *testing.T) {
@@ -284,6 +528,42 @@ func TestSeedAliens(t *testing.T) {
 	}
 }
 
+func TestSeedAliensOccupied(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("a", "north", "b")
+	m.AddLink("b", "south", "a")
+	m.AddAlien("alien1", "a")
+
+	if _, err := m.SeedAliens(4); err == nil {
+		t.Error("expected error for seeding more aliens than the cities have space for")
+	}
+
+	if len(m.aliens) != 1 {
+		t.Errorf("incorrect number of aliens: expected: %d, got: %d", 1, len(m.aliens))
+	}
+
+	alienNames, err := m.SeedAliens(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := []string{"alien2", "alien3", "alien4"}
+	if !reflect.DeepEqual(alienNames, e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, alienNames)
+	}
+
+	if city, _ := m.AlienCity("alien1"); city != "a" {
+		t.Errorf("incorrect city of alien1: expected: %s, got: %s", "a", city)
+	}
+
+	for _, cityName := range []string{"a", "b"} {
+		if occupancy := m.Occupancy(cityName); occupancy != 2 {
+			t.Errorf("incorrect occupancy of %s: expected: %d, got: %d", cityName, 2, occupancy)
+		}
+	}
+}
+
 func TestSeedAliensPriority(t *testing.T) {
 	m := buildMapFixtureEmpty()
 
@@ -313,3 +593,148 @@ func TestSeedAliensPriority(t *testing.T) {
 		)
 	}
 }
+
+func TestSimulationDeterministic(t *testing.T) {
+	run := func(seed int64) string {
+		m := NewMap(rng.NewRand(seed))
+
+		m.AddLink("foo", "north", "bar")
+		m.AddLink("foo", "south", "qu-ux")
+		m.AddLink("foo", "west", "baz")
+		m.AddLink("bar", "south", "foo")
+		m.AddLink("bar", "west", "bee")
+		m.AddLink("bee", "east", "bar")
+		m.AddLink("baz", "east", "foo")
+		m.AddLink("qu-ux", "north", "foo")
+
+		m.SeedAliens(3)
+
+		for i := 0; i < 100 && m.NumAliens() != 0; i++ {
+			if _, err := m.MoveAlien(); err != nil {
+				break
+			}
+
+			m.ExecuteFights()
+		}
+
+		return m.String()
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		r1, r2 := run(seed), run(seed)
+
+		if r1 != r2 {
+			t.Errorf("expected identical results for seed %d: got: %s, %s", seed, r1, r2)
+		}
+	}
+}
+
+func TestTrappedAliens(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("baz", "east", "foo")
+
+	if err := m.AddAlien("alien1", "bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien2", "baz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e := []string{"alien1"}; !reflect.DeepEqual(m.TrappedAliens(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.TrappedAliens())
+	}
+
+	m.AddLink("bar", "south", "foo")
+
+	if m.IsTrapped("alien1") {
+		t.Errorf("expected %s to no longer be trapped", "alien1")
+	}
+
+	// Destroying foo removes the only out links of both bar and baz.
+	m.destroyCity(m.cities["foo"])
+
+	if e := []string{"alien1", "alien2"}; !reflect.DeepEqual(m.TrappedAliens(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.TrappedAliens())
+	}
+
+	if !m.IsImmobilised("alien1") || m.IsImmobilised("alien3") {
+		t.Errorf("incorrect result: expected: %v, got: %v", "alien1 immobilised", m.IsImmobilised("alien1"))
+	}
+}
+
+func TestMobileAliensRandomGraphs(t *testing.T) {
+	r := rng.NewRand(11)
+	cityNames := []string{"a", "b", "c", "d", "e", "f"}
+
+	for i := 0; i < 100; i++ {
+		m := buildMapFixtureEmpty()
+
+		for _, cityName := range cityNames {
+			for _, linkDir := range Directions {
+				if r.Intn(2) == 0 {
+					m.AddLink(cityName, linkDir, cityNames[r.Intn(len(cityNames))])
+				}
+			}
+		}
+
+		if _, err := m.SeedAliens(4); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for j := 0; j < 20; j++ {
+			var err error
+			if j%2 == 0 {
+				_, err = m.MoveAlien()
+			} else {
+				_, err = m.MoveRound()
+			}
+
+			if err == ErrAliensTrapped {
+				break
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			m.ResolveFights()
+
+			// The aliens that are not trapped are kept sorted by name.
+			var e []string
+			for _, alienName := range m.AlienNames() {
+				if !m.IsTrapped(alienName) {
+					e = append(e, alienName)
+				}
+			}
+
+			if len(m.mobile) != len(e) || (len(e) != 0 && !reflect.DeepEqual(m.mobile, e)) {
+				t.Fatalf("incorrect result: expected: %v, got: %v", e, m.mobile)
+			}
+		}
+	}
+}
+
+func TestIsImmobilised(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+
+	cfg := DefaultConfig()
+	cfg.Capacity = 1
+	m.config = cfg
+
+	if err := m.AddAlien("alien1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.AddAlien("alien2", "bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Both aliens are blocked by each other but neither is trapped.
+	if !m.IsImmobilised("alien1") || m.IsTrapped("alien1") {
+		t.Errorf("expected %s to be immobilised but not trapped", "alien1")
+	}
+}
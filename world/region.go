@@ -0,0 +1,66 @@
+package world
+
+// ConnectedRegions returns the connected regions of the map. A region is a set
+// of cities in which every city can be reached from any other city when
+// following links (edges) in either direction. Regions are returned in the
+// order their first city was added to the map and the cities of a region are
+// listed in the order they were added.
+func (m *Map) ConnectedRegions() [][]string {
+	region := make(map[string]int, len(m.cities))
+
+	var regions [][]string
+
+	for _, cityName := range m.CityNames() {
+		if _, ok := region[cityName]; ok {
+			continue
+		}
+
+		// Flood fill the region of the city following both out and in links.
+		id := len(regions)
+		region[cityName] = id
+		stack := []string{cityName}
+
+		for len(stack) != 0 {
+			city := m.cities[stack[len(stack)-1]]
+			stack = stack[:len(stack)-1]
+
+			for _, linkCityName := range city.outLinks {
+				if _, ok := region[linkCityName]; !ok {
+					region[linkCityName] = id
+					stack = append(stack, linkCityName)
+				}
+			}
+
+			for linkCityName := range city.inLinks {
+				if _, ok := region[linkCityName]; !ok {
+					region[linkCityName] = id
+					stack = append(stack, linkCityName)
+				}
+			}
+		}
+
+		regions = append(regions, nil)
+	}
+
+	for _, cityName := range m.CityNames() {
+		id := region[cityName]
+		regions[id] = append(regions[id], cityName)
+	}
+
+	return regions
+}
+
+// LargestRegion returns the cities of the largest connected region of the map
+// in the order they were added. If several regions are equally large, the one
+// whose first city was added first is returned.
+func (m *Map) LargestRegion() []string {
+	var largest []string
+
+	for _, cities := range m.ConnectedRegions() {
+		if len(cities) > len(largest) {
+			largest = cities
+		}
+	}
+
+	return largest
+}
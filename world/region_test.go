@@ -0,0 +1,41 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConnectedRegions(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddCity("baz")
+	m.AddLink("qux", "east", "quux")
+	m.AddLink("corge", "west", "quux")
+	m.AddLink("bar", "south", "foo")
+
+	e := [][]string{{"foo", "bar"}, {"baz"}, {"qux", "quux", "corge"}}
+	if !reflect.DeepEqual(m.ConnectedRegions(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.ConnectedRegions())
+	}
+
+	if l := []string{"qux", "quux", "corge"}; !reflect.DeepEqual(m.LargestRegion(), l) {
+		t.Errorf("incorrect result: expected: %v, got: %v", l, m.LargestRegion())
+	}
+
+	// Destroying a city may split its region.
+	m.destroyCity(m.cities["quux"])
+
+	e = [][]string{{"foo", "bar"}, {"baz"}, {"qux"}, {"corge"}}
+	if !reflect.DeepEqual(m.ConnectedRegions(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.ConnectedRegions())
+	}
+
+	if l := []string{"foo", "bar"}; !reflect.DeepEqual(m.LargestRegion(), l) {
+		t.Errorf("incorrect result: expected: %v, got: %v", l, m.LargestRegion())
+	}
+
+	if r := buildMapFixtureEmpty().LargestRegion(); len(r) != 0 {
+		t.Errorf("incorrect result: expected: %v, got: %v", nil, r)
+	}
+}
@@ -0,0 +1,274 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+)
+
+type (
+	// Round reflects the outcome of a single round in which every alien moves
+	// simultaneously. Moves are listed by alien name.
+	Round struct {
+		// Moves reflects the moves made.
+		Moves []Move
+		// Stays reflects the aliens that chose to stay in their city.
+		Stays []Move
+		// Blocked reflects the moves that were rejected as their destination
+		// could not hold any more arriving aliens. The aliens remain in their
+		// city.
+		Blocked []Move
+		// Collisions reflects the aliens that met on a road. They fight there
+		// once fights are executed.
+		Collisions []Collision
+	}
+
+	// Collision records aliens that met on the road between two cities while
+	// crossing it in opposite directions during a round. The cities are
+	// listed in name order and the aliens sorted by name.
+	Collision struct {
+		Cities [2]string `json:"cities"`
+		Aliens []string  `json:"aliens"`
+	}
+)
+
+// MoveRound moves every alien simultaneously. Unlike MoveAlien, the order in
+// which aliens are examined does not matter as every alien chooses its move
+// based on the map as it was at the start of the round. A round is resolved as
+// follows:
+//
+// 1. Every alien that has at least one valid move lets its movement strategy
+// choose one of its valid moves or to stay in its city.
+// 2. Aliens crossing the same road in opposite directions meet on the road. If
+// at least the fight threshold of aliens meet on a road, they are stopped
+// there and fight once fights are executed, without destroying either city or
+// the road. Otherwise, they pass each other.
+// 3. A city accepts at most as many arriving aliens as it had space for at the
+// start of the round, i.e. aliens leaving a city do not make space for others
+// until the next round. If more aliens attempt to arrive, the ones accepted
+// are chosen uniformly at random and the others are blocked and remain in
+// their city.
+// 4. All accepted moves are applied at once.
+//
+// If no alien can be moved, ErrAliensTrapped is returned. An error is returned
+// if a movement strategy chooses an invalid direction, in which case no alien
+// is moved. Otherwise, the resulting round is returned.
+func (m *Map) MoveRound() (Round, error) {
+	var (
+		round   Round
+		moving  []Move
+		movable bool
+	)
+
+	for _, alienName := range m.mobile {
+		alien := m.aliens[alienName]
+
+		linkDirs := m.validMoves(alien)
+		if len(linkDirs) == 0 {
+			continue
+		}
+
+		movable = true
+
+		city := m.cities[alien.cityName]
+		strategy := m.AlienStrategy(alienName)
+
+		linkDir := strategy.ChooseMove(alienName, city, linkDirs, m, m.rng)
+		if len(linkDir) == 0 {
+			round.Stays = append(round.Stays, Move{Alien: alienName, From: city.name, To: city.name})
+			continue
+		}
+
+		if !containsString(linkDirs, linkDir) {
+			return Round{}, fmt.Errorf("movement strategy %s chose invalid direction %q for alien %s", strategy, linkDir, alienName)
+		}
+
+		moving = append(moving, Move{Alien: alienName, From: city.name, To: city.outLinks[linkDir], Direction: linkDir})
+	}
+
+	if !movable {
+		return Round{}, ErrAliensTrapped
+	}
+
+	met := m.meetOnRoads(moving, &round)
+	accepted := m.acceptArrivals(moving, met)
+
+	for i, move := range moving {
+		switch {
+		case met[i]:
+			// The alien remains in its city until it fights on the road.
+
+		case accepted[i]:
+			round.Moves = append(round.Moves, m.moveAlien(m.aliens[move.Alien], move.Direction))
+
+		default:
+			round.Blocked = append(round.Blocked, move)
+		}
+	}
+
+	m.collisions = append(m.collisions, round.Collisions...)
+	return round, nil
+}
+
+// ApplyCollision records that the given aliens met on the road between the
+// given cities, e.g. as previously recorded during a round, so that they fight
+// there once fights are executed. An error is returned if the collision does
+// not involve at least the fight threshold of aliens, any alien does not
+// exist or does not occupy either city.
+func (m *Map) ApplyCollision(c Collision) error {
+	if uint(len(c.Aliens)) < m.config.FightThreshold {
+		return fmt.Errorf("collision of %d aliens is below the fight threshold of %d", len(c.Aliens), m.config.FightThreshold)
+	}
+
+	for _, alienName := range c.Aliens {
+		alien, ok := m.aliens[alienName]
+		if !ok {
+			return fmt.Errorf("alien %s does not exist", alienName)
+		}
+
+		if alien.cityName != c.Cities[0] && alien.cityName != c.Cities[1] {
+			return fmt.Errorf("alien %s does not occupy city %s or %s", alienName, c.Cities[0], c.Cities[1])
+		}
+	}
+
+	m.collisions = append(m.collisions, c)
+	return nil
+}
+
+// meetOnRoads finds the aliens among the given moves that meet on a road and
+// adds the resulting collisions to the given round. It returns whether or not
+// each move ends in a collision.
+func (m *Map) meetOnRoads(moving []Move, round *Round) []bool {
+	var roads [][2]string
+
+	// Group the moves by the road they cross regardless of their direction.
+	crossing := make(map[[2]string][]int)
+
+	for i, move := range moving {
+		road := [2]string{move.From, move.To}
+		if road[1] < road[0] {
+			road[0], road[1] = road[1], road[0]
+		}
+
+		if _, ok := crossing[road]; !ok {
+			roads = append(roads, road)
+		}
+
+		crossing[road] = append(crossing[road], i)
+	}
+
+	met := make([]bool, len(moving))
+
+	for _, road := range roads {
+		moves := crossing[road]
+
+		var forward, backward bool
+		for _, i := range moves {
+			if moving[i].From == road[0] {
+				forward = true
+			} else {
+				backward = true
+			}
+		}
+
+		if !forward || !backward || uint(len(moves)) < m.config.FightThreshold {
+			continue
+		}
+
+		c := Collision{Cities: road}
+		for _, i := range moves {
+			met[i] = true
+			c.Aliens = append(c.Aliens, moving[i].Alien)
+		}
+
+		sort.Strings(c.Aliens)
+		round.Collisions = append(round.Collisions, c)
+	}
+
+	return met
+}
+
+// acceptArrivals decides which of the given moves that do not end in a
+// collision may arrive at their destination. A city accepts as many arriving
+// aliens as it had space for at the start of the round, chosen uniformly at
+// random if more attempt to arrive. It returns whether or not each move is
+// accepted.
+func (m *Map) acceptArrivals(moving []Move, met []bool) []bool {
+	var cityNames []string
+
+	arrivals := make(map[string][]int)
+
+	for i, move := range moving {
+		if met[i] {
+			continue
+		}
+
+		if _, ok := arrivals[move.To]; !ok {
+			cityNames = append(cityNames, move.To)
+		}
+
+		arrivals[move.To] = append(arrivals[move.To], i)
+	}
+
+	accepted := make([]bool, len(moving))
+
+	for _, cityName := range cityNames {
+		moves := arrivals[cityName]
+		space := int(m.config.Capacity) - len(m.cities[cityName].alienOccupancy)
+
+		if len(moves) <= space {
+			for _, i := range moves {
+				accepted[i] = true
+			}
+
+			continue
+		}
+
+		for j, k := range m.rng.Perm(len(moves)) {
+			if j < space {
+				accepted[moves[k]] = true
+			}
+		}
+	}
+
+	return accepted
+}
+
+// resolveCollisions resolves a fight between the aliens that met on a road for
+// every collision since fights were last executed using the map's fight
+// resolver. The resolver is given a placeholder city for the road that holds
+// the fighting aliens. Neither city nor the road is affected by the outcome,
+// but all the aliens are killed if it destroys the city. The resulting fights
+// are returned in the order the collisions happened.
+func (m *Map) resolveCollisions() []Fight {
+	var fights []Fight
+
+	for _, c := range m.collisions {
+		road := &City{
+			road:           []string{c.Cities[0], c.Cities[1]},
+			alienOccupancy: make(map[string]*Alien, len(c.Aliens)),
+		}
+
+		for _, alienName := range c.Aliens {
+			if alien, ok := m.aliens[alienName]; ok {
+				road.alienOccupancy[alienName] = alien
+			}
+		}
+
+		if len(road.alienOccupancy) == 0 {
+			continue
+		}
+
+		f := Fight{Road: road.road, Aliens: road.AlienNames()}
+
+		outcome := m.resolver.ResolveFight(road, f.Aliens, m.rng)
+		if outcome.DestroyCity {
+			outcome.Killed = f.Aliens
+		}
+
+		m.applyCasualties(&f, outcome)
+		fights = append(fights, f)
+	}
+
+	m.collisions = nil
+	return fights
+}
@@ -0,0 +1,211 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func TestMoveRoundCollision(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddAlien("alien1", "foo")
+	m.AddAlien("alien2", "bar")
+
+	round, err := m.MoveRound()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := []Collision{{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien2"}}}
+	if !reflect.DeepEqual(round.Collisions, e) || len(round.Moves) != 0 {
+		t.Fatalf("incorrect result: expected: %v, got: %+v", e, round)
+	}
+
+	// The aliens remain in their cities until they fight on the road.
+	if city, _ := m.AlienCity("alien1"); city != "foo" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "foo", city)
+	}
+
+	if d := m.ExecuteFights(); len(d) != 0 {
+		t.Errorf("incorrect result: expected no destroyed cities, got: %v", d)
+	}
+
+	if m.NumAliens() != 0 || m.NumCities() != 2 {
+		t.Errorf("incorrect result: expected: %v, got: %v", "no aliens and both cities", m)
+	}
+
+	if linkCityName, ok := m.cities["foo"].Link("north"); !ok || linkCityName != "bar" {
+		t.Errorf("incorrect result: expected road to survive, got: %v", m)
+	}
+}
+
+func TestMoveRoundPass(t *testing.T) {
+	m, err := NewMapWithConfig(rng.NewRand(1), Config{Capacity: 3, FightThreshold: 3, MaxEdges: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddAlien("alien1", "foo")
+	m.AddAlien("alien2", "bar")
+
+	round, err := m.MoveRound()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Two aliens are below the fight threshold, so they pass each other.
+	e := []Move{
+		{Alien: "alien1", From: "foo", To: "bar", Direction: "north"},
+		{Alien: "alien2", From: "bar", To: "foo", Direction: "south"},
+	}
+
+	if !reflect.DeepEqual(round.Moves, e) || len(round.Collisions) != 0 {
+		t.Errorf("incorrect result: expected: %v, got: %+v", e, round)
+	}
+
+	if city, _ := m.AlienCity("alien1"); city != "bar" {
+		t.Errorf("incorrect result: expected: %v, got: %v", "bar", city)
+	}
+}
+
+func TestMoveRoundArrivals(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("hub", "north", "exit")
+	m.AddLink("foo", "south", "hub")
+	m.AddLink("bar", "east", "hub")
+
+	for alienName, cityName := range map[string]string{"alien1": "hub", "alien2": "foo", "alien3": "bar"} {
+		if err := m.AddAlien(alienName, cityName); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	accepted := make(map[string]int)
+
+	for i := int64(0); i < 200; i++ {
+		c := m.CloneWithRand(rng.NewRand(i))
+
+		round, err := c.MoveRound()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// alien1 leaving the hub does not make space for a second arrival.
+		if len(round.Moves) != 2 || len(round.Blocked) != 1 || round.Moves[0].Alien != "alien1" {
+			t.Fatalf("incorrect result: expected alien1 and one other alien to move, got: %+v", round)
+		}
+
+		blocked := round.Blocked[0]
+		if city, _ := c.AlienCity(blocked.Alien); city != blocked.From {
+			t.Errorf("incorrect result: expected: %v, got: %v", blocked.From, city)
+		}
+
+		accepted[round.Moves[1].Alien]++
+	}
+
+	if accepted["alien2"] < 60 || accepted["alien3"] < 60 {
+		t.Errorf("incorrect result: expected arrivals to be chosen uniformly, got: %v", accepted)
+	}
+}
+
+func TestMoveRoundTrapped(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddAlien("alien1", "bar")
+
+	if _, err := m.MoveRound(); err != ErrAliensTrapped {
+		t.Errorf("incorrect result: expected: %v, got: %v", ErrAliensTrapped, err)
+	}
+}
+
+func TestResolveFightsCollisionResolver(t *testing.T) {
+	testCases := []struct {
+		resolver  FightResolver
+		survivors int
+	}{
+		{Winner{P: 1}, 1},
+		{CityDamage{Limit: 1}, 0},
+		{CityDamage{Limit: 5}, 0},
+		{RoadsOnly{}, 0},
+	}
+
+	for _, tc := range testCases {
+		m := buildMapFixtureEmpty()
+		m.SetFightResolver(tc.resolver)
+
+		m.AddLink("foo", "north", "bar")
+		m.AddLink("bar", "south", "foo")
+		m.AddAlien("alien1", "foo")
+		m.AddAlien("alien2", "bar")
+		m.ApplyCollision(Collision{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien2"}})
+
+		fights := m.ResolveFights()
+		if len(fights) != 1 || !reflect.DeepEqual(fights[0].Road, []string{"bar", "foo"}) || len(fights[0].City) != 0 {
+			t.Errorf("unexpected fights for %s: %v", tc.resolver, fights)
+			continue
+		}
+
+		if len(fights[0].Killed) != 2-tc.survivors || int(m.NumAliens()) != tc.survivors {
+			t.Errorf("incorrect number of survivors for %s: expected: %d, got: %d", tc.resolver, tc.survivors, m.NumAliens())
+		}
+
+		// A survivor remains in its city.
+		for _, alienName := range m.AlienNames() {
+			if city, _ := m.AlienCity(alienName); m.Occupancy(city) != 1 {
+				t.Errorf("incorrect occupancy of %s for %s: expected: %d, got: %d", city, tc.resolver, 1, m.Occupancy(city))
+			}
+		}
+
+		// Neither city nor the road is affected.
+		for _, cityName := range []string{"foo", "bar"} {
+			if damage, ok := m.CityDamage(cityName); !ok || damage != 0 || len(m.cities[cityName].outLinks) != 1 {
+				t.Errorf("unexpected city %s for %s: %v", cityName, tc.resolver, m)
+			}
+		}
+	}
+}
+
+func TestApplyCollision(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddCity("baz")
+	m.AddAlien("alien1", "foo")
+	m.AddAlien("alien2", "bar")
+	m.AddAlien("alien3", "baz")
+
+	testCases := []struct {
+		c     Collision
+		valid bool
+	}{
+		{Collision{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1"}}, false},
+		{Collision{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien4"}}, false},
+		{Collision{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien3"}}, false},
+		{Collision{Cities: [2]string{"bar", "foo"}, Aliens: []string{"alien1", "alien2"}}, true},
+	}
+
+	for _, tc := range testCases {
+		err := m.ApplyCollision(tc.c)
+
+		if tc.valid && err != nil {
+			t.Errorf("unexpected error for %v: %v", tc.c, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("expected error for %v", tc.c)
+		}
+	}
+
+	m.ExecuteFights()
+
+	if e := []string{"alien3"}; !reflect.DeepEqual(m.AlienNames(), e) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, m.AlienNames())
+	}
+}
@@ -0,0 +1,136 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+// State reflects the complete state of a map, i.e. everything required to
+// restore it later on and continue exactly where it left off: its
+// configuration, cities, links (edges), aliens, their attributes and the
+// cities they have visited, destroyed cities, pending collisions, movement
+// strategies, fight resolver, roster and the state of its random number
+// generator. Movement strategies and the fight resolver are captured by their
+// specification.
+type State struct {
+	Config          Config              `json:"config"`
+	Rand            uint64              `json:"rand"`
+	Map             json.RawMessage     `json:"map"`
+	Destroyed       []Destruction       `json:"destroyed"`
+	Collisions      []Collision         `json:"collisions,omitempty"`
+	Visited         map[string][]string `json:"visited"`
+	Strategy        string              `json:"strategy"`
+	AlienStrategies map[string]string   `json:"alien_strategies"`
+	Resolver        string              `json:"resolver,omitempty"`
+	Roster          Roster              `json:"roster"`
+}
+
+// State returns the complete state of the map. An error is returned if the map
+// has no random number generator or cannot be encoded. Only movement
+// strategies accepted by ParseStrategy and fight resolvers accepted by
+// ParseFightResolver can be restored from the state.
+func (m *Map) State() (State, error) {
+	if m.rng == nil {
+		return State{}, errors.New("map has no random number generator")
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return State{}, err
+	}
+
+	s := State{
+		Config:          m.config,
+		Rand:            m.rng.State(),
+		Map:             data,
+		Destroyed:       m.DestroyedCities(),
+		Collisions:      append([]Collision(nil), m.collisions...),
+		Visited:         make(map[string][]string, len(m.aliens)),
+		Strategy:        m.strategy.String(),
+		AlienStrategies: make(map[string]string, len(m.alienStrategies)),
+		Resolver:        m.resolver.String(),
+		Roster:          m.roster.clone(),
+	}
+
+	for alienName, alien := range m.aliens {
+		s.Visited[alienName] = sortedKeys(alien.visited)
+	}
+
+	for alienName, strategy := range m.alienStrategies {
+		s.AlienStrategies[alienName] = strategy.String()
+	}
+
+	return s, nil
+}
+
+// NewMapFromState returns a reference to a new Map restored from the given
+// state. An error is returned if the state is invalid.
+func NewMapFromState(s State) (*Map, error) {
+	m, err := NewMapWithConfig(rng.NewRandFromState(s.Rand), s.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(s.Map, m); err != nil {
+		return nil, err
+	}
+
+	// The roster is only restored once the aliens are, as their attributes
+	// may have changed since they took on their listed attributes.
+	if err := s.Roster.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.roster = s.Roster.clone()
+
+	m.destroyed = append(m.destroyed, s.Destroyed...)
+
+	for _, c := range s.Collisions {
+		if err := m.ApplyCollision(c); err != nil {
+			return nil, fmt.Errorf("invalid collision: %v", err)
+		}
+	}
+
+	for alienName, cityNames := range s.Visited {
+		alien, ok := m.aliens[alienName]
+		if !ok {
+			return nil, fmt.Errorf("invalid visited cities: alien %s does not exist", alienName)
+		}
+
+		for _, cityName := range cityNames {
+			alien.visited[cityName] = true
+		}
+	}
+
+	if len(s.Strategy) != 0 {
+		strategy, err := ParseStrategy(s.Strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		m.strategy = strategy
+	}
+
+	for alienName, spec := range s.AlienStrategies {
+		strategy, err := ParseStrategy(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid strategy of alien %s: %v", alienName, err)
+		}
+
+		m.alienStrategies[alienName] = strategy
+	}
+
+	if len(s.Resolver) != 0 {
+		resolver, err := ParseFightResolver(s.Resolver)
+		if err != nil {
+			return nil, err
+		}
+
+		m.resolver = resolver
+	}
+
+	return m, nil
+}
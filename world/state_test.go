@@ -0,0 +1,213 @@
+package world
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("bar", "east", "baz")
+	m.AddLink("baz", "west", "bar")
+	m.AddLink("qux", "west", "baz")
+	m.AddCity("quux")
+
+	m.SeedAliens(5)
+	m.ExecuteFights()
+
+	for i := 0; i < 5; i++ {
+		m.MoveAlien()
+		m.ExecuteFights()
+	}
+
+	state, err := m.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded State
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := NewMapFromState(decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.String() != m.String() || len(restored.DestroyedCities()) != len(m.DestroyedCities()) {
+		t.Fatalf("incorrect result: expected: %v, got: %v", m, restored)
+	}
+
+	if e, a := m.TrappedAliens(), restored.TrappedAliens(); len(e) != len(a) {
+		t.Errorf("incorrect result: expected: %v, got: %v", e, a)
+	}
+
+	// The restored map continues exactly where the original left off.
+	for i := 0; i < 20; i++ {
+		m1, err1 := m.MoveAlien()
+		m2, err2 := restored.MoveAlien()
+
+		if m1 != m2 || err1 != err2 {
+			t.Fatalf("incorrect result: expected: %v, got: %v", m1, m2)
+		}
+
+		m.ExecuteFights()
+		restored.ExecuteFights()
+	}
+}
+
+func TestStateStrategies(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddAlien("alien1", "foo")
+	m.SetStrategy(AvoidAliens{})
+	m.SetAlienStrategy("alien1", Lazy{P: 0.5})
+
+	for i := 0; i < 10; i++ {
+		m.MoveAlien()
+	}
+
+	state, err := m.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := NewMapFromState(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !restored.Equal(m) {
+		t.Errorf("incorrect result: expected: %v, got: %v", m, restored)
+	}
+
+	if s := restored.Strategy(); s != (AvoidAliens{}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", AvoidAliens{}, s)
+	}
+
+	if s := restored.AlienStrategy("alien1"); s != (Lazy{P: 0.5}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", Lazy{P: 0.5}, s)
+	}
+
+	state.AlienStrategies["alien1"] = "teleport"
+
+	if _, err := NewMapFromState(state); err == nil {
+		t.Error("expected error for invalid strategy")
+	}
+}
+
+func TestStateFightResolver(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.SetFightResolver(CityDamage{Limit: 3})
+	m.ExecuteFights()
+
+	state, err := m.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := NewMapFromState(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !restored.Equal(m) {
+		t.Errorf("incorrect result: expected: %v, got: %v", m, restored)
+	}
+
+	if damage, _ := restored.CityDamage("foo"); damage != 1 {
+		t.Errorf("incorrect result: expected: %d, got: %d", 1, damage)
+	}
+
+	if r := restored.FightResolver(); r != (CityDamage{Limit: 3}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", CityDamage{Limit: 3}, r)
+	}
+
+	state.Resolver = "nuke"
+
+	if _, err := NewMapFromState(state); err == nil {
+		t.Error("expected error for invalid fight resolver")
+	}
+}
+
+func TestStateDestroyed(t *testing.T) {
+	m := buildMapFixtureSimple()
+	m.AddLink("bar", "east", "baz")
+	m.AddLink("baz", "west", "bar")
+	m.AddAlien("alien5", "baz")
+
+	// Destroying foo and bar leaves them in the order of cities and their
+	// directions in the order of links of the remaining cities.
+	m.ExecuteFights()
+
+	if len(m.DestroyedCities()) != 2 {
+		t.Fatalf("incorrect destroyed cities: %v", m.DestroyedCities())
+	}
+
+	state, err := m.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := NewMapFromState(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !restored.Equal(m) || !m.Equal(restored) {
+		t.Errorf("incorrect result: expected: %v, got: %v", m, restored)
+	}
+}
+
+func TestStateRoster(t *testing.T) {
+	m := buildMapFixtureSimple()
+
+	roster := Roster{
+		Aliens:  map[string]Attributes{"alien1": {Health: 3}, "alien9": {Species: "queen"}},
+		Species: []Species{{Name: "grey", Weight: 1, Strength: Range{Min: 1, Max: 2}}},
+	}
+
+	if err := m.SetRoster(roster); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The injured alien keeps its remaining health rather than its listed
+	// health.
+	m.SetAlienAttributes("alien1", DefaultAttributes())
+
+	state, err := m.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored, err := NewMapFromState(state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !restored.Equal(m) {
+		t.Errorf("incorrect result: expected: %v, got: %v", m, restored)
+	}
+
+	if !reflect.DeepEqual(restored.Roster(), roster) {
+		t.Errorf("incorrect result: expected: %v, got: %v", roster, restored.Roster())
+	}
+
+	state.Roster.Species[0].Weight = 0
+
+	if _, err := NewMapFromState(state); err == nil {
+		t.Error("expected error for invalid roster")
+	}
+}
@@ -0,0 +1,186 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+type (
+	// MapView reflects a read-only view of a map that movement strategies may
+	// base their decisions on.
+	MapView interface {
+		// Occupancy returns the number of aliens occupying the city with the
+		// given name.
+		Occupancy(cityName string) int
+		// HasVisited returns a boolean on whether or not the alien with the
+		// given name has ever occupied the city with the given name.
+		HasVisited(alienName, cityName string) bool
+	}
+
+	// MovementStrategy reflects how an alien chooses its moves. Given the
+	// alien, the city it occupies, the directions it may validly move in (at
+	// least one, in sorted order) and a view of the map, a strategy returns the
+	// direction to move in or an empty string to stay. All randomness must be
+	// drawn from the given random number generator. Strategies must be
+	// stateless as they may be shared between aliens and maps.
+	MovementStrategy interface {
+		ChooseMove(alienName string, city *City, linkDirs []string, view MapView, r *rng.Rand) string
+		// String returns the specification of the strategy as accepted by
+		// ParseStrategy.
+		String() string
+	}
+
+	// RandomWalk implements a MovementStrategy that moves in a uniformly
+	// random valid direction.
+	RandomWalk struct{}
+
+	// PreferUnvisited implements a MovementStrategy that moves in a uniformly
+	// random valid direction leading to a city the alien has never occupied,
+	// if any, or otherwise in any uniformly random valid direction.
+	PreferUnvisited struct{}
+
+	// SeekAliens implements a MovementStrategy that moves in a uniformly
+	// random valid direction leading to the most occupied city.
+	SeekAliens struct{}
+
+	// AvoidAliens implements a MovementStrategy that moves in a uniformly
+	// random valid direction leading to the least occupied city.
+	AvoidAliens struct{}
+
+	// Lazy implements a MovementStrategy that only moves, in a uniformly
+	// random valid direction, with probability P and otherwise stays.
+	Lazy struct {
+		P float64
+	}
+)
+
+// ParseStrategy returns the movement strategy with the given specification:
+// random, unvisited, seek, avoid or lazy:<p> where p is the probability of the
+// alien moving, e.g. lazy:0.5. An error is returned if the specification is
+// invalid.
+func ParseStrategy(spec string) (MovementStrategy, error) {
+	name, arg := spec, ""
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		name, arg = spec[:i], spec[i+1:]
+	}
+
+	switch strings.ToLower(name) {
+	case "random":
+		return RandomWalk{}, checkNoArg(spec, arg)
+
+	case "unvisited":
+		return PreferUnvisited{}, checkNoArg(spec, arg)
+
+	case "seek":
+		return SeekAliens{}, checkNoArg(spec, arg)
+
+	case "avoid":
+		return AvoidAliens{}, checkNoArg(spec, arg)
+
+	case "lazy":
+		p, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(p) || p <= 0 || p > 1 {
+			return nil, fmt.Errorf("invalid strategy %q: probability must be in (0, 1]", spec)
+		}
+
+		return Lazy{P: p}, nil
+	}
+
+	return nil, fmt.Errorf("unknown strategy %q: must be one of random, unvisited, seek, avoid or lazy:<p>", spec)
+}
+
+// checkNoArg returns an error if a strategy that takes no argument was given
+// one.
+func checkNoArg(spec, arg string) error {
+	if len(arg) != 0 {
+		return fmt.Errorf("invalid strategy %q: takes no argument", spec)
+	}
+
+	return nil
+}
+
+// ChooseMove implements the MovementStrategy interface.
+func (RandomWalk) ChooseMove(_ string, _ *City, linkDirs []string, _ MapView, r *rng.Rand) string {
+	return linkDirs[r.Intn(len(linkDirs))]
+}
+
+// String implements the MovementStrategy interface.
+func (RandomWalk) String() string { return "random" }
+
+// ChooseMove implements the MovementStrategy interface.
+func (PreferUnvisited) ChooseMove(alienName string, city *City, linkDirs []string, view MapView, r *rng.Rand) string {
+	var unvisited []string
+
+	for _, linkDir := range linkDirs {
+		if linkCityName, _ := city.Link(linkDir); !view.HasVisited(alienName, linkCityName) {
+			unvisited = append(unvisited, linkDir)
+		}
+	}
+
+	if len(unvisited) == 0 {
+		unvisited = linkDirs
+	}
+
+	return unvisited[r.Intn(len(unvisited))]
+}
+
+// String implements the MovementStrategy interface.
+func (PreferUnvisited) String() string { return "unvisited" }
+
+// ChooseMove implements the MovementStrategy interface.
+func (SeekAliens) ChooseMove(_ string, city *City, linkDirs []string, view MapView, r *rng.Rand) string {
+	return chooseByOccupancy(city, linkDirs, view, r, func(a, b int) bool { return a > b })
+}
+
+// String implements the MovementStrategy interface.
+func (SeekAliens) String() string { return "seek" }
+
+// ChooseMove implements the MovementStrategy interface.
+func (AvoidAliens) ChooseMove(_ string, city *City, linkDirs []string, view MapView, r *rng.Rand) string {
+	return chooseByOccupancy(city, linkDirs, view, r, func(a, b int) bool { return a < b })
+}
+
+// String implements the MovementStrategy interface.
+func (AvoidAliens) String() string { return "avoid" }
+
+// ChooseMove implements the MovementStrategy interface.
+func (l Lazy) ChooseMove(alienName string, city *City, linkDirs []string, view MapView, r *rng.Rand) string {
+	if r.Float64() >= l.P {
+		return ""
+	}
+
+	return RandomWalk{}.ChooseMove(alienName, city, linkDirs, view, r)
+}
+
+// String implements the MovementStrategy interface.
+func (l Lazy) String() string {
+	return "lazy:" + strconv.FormatFloat(l.P, 'g', -1, 64)
+}
+
+// chooseByOccupancy returns a uniformly random direction among the given
+// directions whose linked city has the best occupancy according to better.
+func chooseByOccupancy(city *City, linkDirs []string, view MapView, r *rng.Rand, better func(a, b int) bool) string {
+	var (
+		best    []string
+		bestOcc int
+	)
+
+	for _, linkDir := range linkDirs {
+		linkCityName, _ := city.Link(linkDir)
+		occ := view.Occupancy(linkCityName)
+
+		switch {
+		case len(best) == 0 || better(occ, bestOcc):
+			best, bestOcc = []string{linkDir}, occ
+
+		case occ == bestOcc:
+			best = append(best, linkDir)
+		}
+	}
+
+	return best[r.Intn(len(best))]
+}
@@ -0,0 +1,183 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/alexanderbez/alien-invasion/rng"
+)
+
+func buildMapFixtureStar(t *testing.T) *Map {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("center", "north", "n")
+	m.AddLink("center", "south", "s")
+	m.AddLink("center", "east", "e")
+	m.AddLink("center", "west", "w")
+
+	for alienName, cityName := range map[string]string{"alien1": "center", "alien2": "n", "alien3": "e"} {
+		if err := m.AddAlien(alienName, cityName); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	return m
+}
+
+func TestParseStrategy(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected MovementStrategy
+	}{
+		{"random", RandomWalk{}},
+		{"Unvisited", PreferUnvisited{}},
+		{"seek", SeekAliens{}},
+		{"avoid", AvoidAliens{}},
+		{"lazy:0.25", Lazy{P: 0.25}},
+		{"lazy:1", Lazy{P: 1}},
+	}
+
+	for _, tc := range testCases {
+		s, err := ParseStrategy(tc.spec)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", tc.spec, err)
+			continue
+		}
+
+		if s != tc.expected {
+			t.Errorf("incorrect result: expected: %v, got: %v", tc.expected, s)
+		}
+
+		// The specification of a strategy parses back into the same strategy.
+		if p, _ := ParseStrategy(s.String()); p != s {
+			t.Errorf("incorrect result: expected: %v, got: %v", s, p)
+		}
+	}
+
+	for _, spec := range []string{"", "teleport", "seek:1", "lazy", "lazy:0", "lazy:1.5", "lazy:x", "lazy:NaN", "lazy:Inf"} {
+		if _, err := ParseStrategy(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	m := buildMapFixtureStar(t)
+	city := m.cities["center"]
+	linkDirs := m.validMoves(m.aliens["alien1"])
+
+	testCases := []struct {
+		strategy MovementStrategy
+		expected map[string]bool
+	}{
+		{RandomWalk{}, map[string]bool{"east": true, "north": true, "south": true, "west": true}},
+		{SeekAliens{}, map[string]bool{"east": true, "north": true}},
+		{AvoidAliens{}, map[string]bool{"south": true, "west": true}},
+	}
+
+	for _, tc := range testCases {
+		chosen := make(map[string]bool)
+
+		for i := 0; i < 200; i++ {
+			chosen[tc.strategy.ChooseMove("alien1", city, linkDirs, m, m.rng)] = true
+		}
+
+		if len(chosen) != len(tc.expected) {
+			t.Errorf("incorrect result for %s: expected: %v, got: %v", tc.strategy, tc.expected, chosen)
+		}
+
+		for linkDir := range chosen {
+			if !tc.expected[linkDir] {
+				t.Errorf("incorrect result for %s: expected: %v, got: %v", tc.strategy, tc.expected, chosen)
+			}
+		}
+	}
+}
+
+func TestPreferUnvisited(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("foo", "south", "baz")
+	m.AddLink("bar", "south", "foo")
+	m.AddLink("baz", "north", "foo")
+	m.AddAlien("alien1", "foo")
+	m.SetStrategy(PreferUnvisited{})
+
+	// The alien returns to foo in between, so it must visit both bar and baz
+	// within four moves.
+	for i := 0; i < 4; i++ {
+		if _, err := m.MoveAlien(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, cityName := range []string{"foo", "bar", "baz"} {
+		if !m.HasVisited("alien1", cityName) {
+			t.Errorf("expected alien1 to have visited %s", cityName)
+		}
+	}
+
+	if m.HasVisited("alien1", "qux") || m.HasVisited("alien2", "foo") {
+		t.Error("unexpected visit")
+	}
+}
+
+func TestLazy(t *testing.T) {
+	m := buildMapFixtureEmpty()
+
+	m.AddLink("foo", "north", "bar")
+	m.AddLink("bar", "south", "foo")
+	m.AddAlien("alien1", "foo")
+	m.SetAlienStrategy("alien1", Lazy{P: 0.25})
+
+	if s := m.AlienStrategy("alien1"); s != (Lazy{P: 0.25}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", Lazy{P: 0.25}, s)
+	}
+
+	const trials = 4000
+
+	stays := 0
+	for i := 0; i < trials; i++ {
+		move, err := m.MoveAlien()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if move.Stayed() {
+			if move.From != move.To {
+				t.Fatalf("incorrect result: expected alien1 to stay, got: %+v", move)
+			}
+
+			stays++
+		}
+	}
+
+	if r := float64(stays) / trials; r < 0.72 || r > 0.78 {
+		t.Errorf("incorrect frequency of stays: expected: %v, got: %v", 0.75, r)
+	}
+
+	m.SetAlienStrategy("alien1", nil)
+
+	if s := m.AlienStrategy("alien1"); s != (RandomWalk{}) {
+		t.Errorf("incorrect result: expected: %v, got: %v", RandomWalk{}, s)
+	}
+}
+
+func TestInvalidStrategy(t *testing.T) {
+	m := buildMapFixtureStar(t)
+	m.SetStrategy(fixedStrategy("up"))
+
+	if _, err := m.MoveAlien(); err == nil {
+		t.Error("expected error for invalid direction")
+	}
+}
+
+// fixedStrategy implements a MovementStrategy that always chooses the same
+// direction.
+type fixedStrategy string
+
+func (s fixedStrategy) ChooseMove(_ string, _ *City, _ []string, _ MapView, _ *rng.Rand) string {
+	return string(s)
+}
+
+func (s fixedStrategy) String() string { return string(s) }